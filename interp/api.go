@@ -1,6 +1,9 @@
 package interp
 
 import (
+	"fmt"
+	"strings"
+
 	"golang.org/x/tools/go/types"
 	"golang.org/x/tools/go/types/typeutil"
 )
@@ -13,10 +16,24 @@ func NewInterpreter(pkgs []*Package, pkgMap map[string]*types.Package, typeMap *
 	return newInterp(pkgs, pkgMap, typeMap)
 }
 
+// A PendingError is returned by Run for declarations that refer to names that
+// aren't declared yet. The declarations are kept, and take effect once later
+// inputs declare those names.
+type PendingError struct {
+	Decls []string // The pending declarations
+	Names []string // The names they're waiting for
+}
+
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("%s pending: waiting for %s to be declared",
+		strings.Join(e.Decls, ", "), strings.Join(e.Names, ", "))
+}
+
 type Package struct {
-	Name string
-	Objs map[string]Object
-	Pkg  *types.Package
+	Name    string
+	Objs    map[string]Object
+	Pkg     *types.Package
+	Proxies map[types.Type]ProxyMaker // For the interface types of the package
 }
 
 func (pkg *Package) Lookup(s string) (Object, bool) {
@@ -0,0 +1,59 @@
+package interp
+
+import "testing"
+
+func TestArray(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "value semantics",
+			inputs: []string{
+				"a := [3]int{1, 2, 3}",
+				"b := a\nb[0] = 10",
+				"f := func(x [3]int) { x[1] = 20 }\nf(a)",
+				"p := &a\np[2] = 30",
+				"fmt.Println(a, b, a == b)",
+			},
+			want: "[1 2 30] [10 2 3] false\n",
+		},
+		{
+			name: "zero value and comparison",
+			inputs: []string{
+				"var a [2]string",
+				"b := [2]string{}",
+				`a[1] = "x"`,
+				`fmt.Printf("%q %v\n", a, a == b)`,
+				`b[1] = "x"`,
+				"fmt.Println(a == b)",
+			},
+			want: "[\"\" \"x\"] false\ntrue\n",
+		},
+		{
+			name: "map keys",
+			inputs: []string{
+				"m := map[[2]int]string{{0, 1}: \"a\"}",
+				"k := [2]int{1, 0}",
+				`m[k] = "b"`,
+				"k[0] = 0\nk[1] = 1",
+				`fmt.Println(m[k], m[[2]int{1, 0}])`,
+			},
+			want: "a b\n",
+		},
+		{
+			name: "range over a copy",
+			inputs: []string{
+				"a := [3]int{1, 2, 3}",
+				"for i, v := range a {\n\tif i == 0 {\n\t\ta[2] = 100\n\t}\n\tfmt.Print(v, \" \")\n}",
+				"fmt.Println(a[2])",
+			},
+			want: "1 2 3 100\n",
+		},
+		{
+			name: "nil pointer to array",
+			inputs: []string{
+				"var p *[2]int",
+				"fmt.Println(p[0])",
+			},
+			want: "error: panic: runtime error: invalid memory address or nil pointer dereference\n",
+		},
+	})
+}
@@ -11,27 +11,54 @@ import (
 // TODO: this only covers very simple assignment. There are more complicated rules
 //   not yet implemented (see http://golang.org/ref/spec#Assignments), such as:
 //   1) nil
-//   2) Blank identifier
 
 func (env *environ) getAssignmentLhs(exprs []ast.Expr) ([]Object, map[int]bool) {
 	objs := make([]Object, len(exprs))
 	mapIndexExprs := make(map[int]bool)
 	for i, expr := range exprs {
+		if isBlankIdent(expr) {
+			// Leave the zero Object in place; assignObj ignores it
+			continue
+		}
 		if isMapIndexExpr(env, expr) {
 			mapIndexExprs[i] = true
 		}
+		if sel, ok := expr.(*ast.SelectorExpr); ok && isFieldSelector(env, sel) {
+			// Assign to the field as it's stored, which may be a recursive field
+			objs[i] = Object{
+				Value: env.evalField(sel),
+				Typ:   env.info.TypeOf(sel),
+			}
+			continue
+		}
 		objs[i] = env.Eval(expr)[0]
 	}
 	return objs, mapIndexExprs
 }
 
+// isBlankIdent reports whether expr is the blank identifier "_".
+func isBlankIdent(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
 // assignObj assigns value of rObj to lObj.
-// lObj.Value must be a settable reflect.Value.
+// lObj.Value must be a settable reflect.Value, or nil if lObj stands for the
+// blank identifier, in which case the value is discarded.
 // rObj.Value must be a reflect.Value unless it represents untyped nil.
-func assignObj(lObj, rObj Object) {
-	lVal := lObj.Value.(reflect.Value)
+func (env *environ) assignObj(lObj, rObj Object) {
+	lVal, ok := lObj.Value.(reflect.Value)
+	if !ok {
+		// Blank identifier
+		return
+	}
+	rObj = env.interp.proxyFor(rObj, lVal.Type())
 	switch rVal := rObj.Value.(type) {
 	case reflect.Value:
+		if lVal.Type() == recursiveFieldType {
+			setRecursiveField(lVal, rVal)
+			return
+		}
 		lVal.Set(rVal)
 	default:
 		// Must be untyped nil
@@ -47,6 +74,7 @@ func (env *environ) assignMapIndex(expr ast.Expr, rObj Object) {
 	mapVal := mapObj.Value.(reflect.Value)
 	keyVal := keyObj.Value.(reflect.Value)
 
+	rObj = env.interp.proxyFor(rObj, mapVal.Type().Elem())
 	rVal, ok := rObj.Value.(reflect.Value)
 	if ok {
 		mapVal.SetMapIndex(keyVal, rVal)
@@ -3,114 +3,270 @@ package interp
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"log"
 	"reflect"
-)
 
-// TODO: make() built-in
-//   * chan types
-//   * slice types
-//   * map types
+	"golang.org/x/tools/go/exact"
+	"golang.org/x/tools/go/types"
+)
 
 func (env *environ) evalBuiltinCall(callExpr *ast.CallExpr, async bool) []Object {
-	// TODO: implement builtins
 	builtinName := callExpr.Fun.(*ast.Ident).Name
-	var results []Object
+	typ := env.info.TypeOf(callExpr)
 	switch builtinName {
 	case "append":
-		log.Fatal("append function not implemented yet")
-	case "cap":
-		log.Fatal("cap function not implemented yet")
-	case "close":
-		argObj := env.evalFuncArgs(callExpr.Args)[0]
-		argObj.Value.(reflect.Value).Close()
+		argObjs := env.evalExprs(callExpr.Args)
+		obj := env.evalAppend(argObjs, callExpr.Ellipsis != token.NoPos, typ)
+		return []Object{obj}
+	case "cap", "len":
+		// Calls that are constant were already evaluated by the type checker
+		argObj := getTypedObject(env.Eval(callExpr.Args[0])[0])
+		argVal := argObj.Value.(reflect.Value)
+		var n int
+		switch t := argObj.Typ.Underlying().(type) {
+		case *types.Pointer:
+			// Pointer to array: the length is part of the type, so the pointer
+			// isn't dereferenced, and may be nil
+			n = int(t.Elem().Underlying().(*types.Array).Len())
+		default:
+			if builtinName == "cap" {
+				n = argVal.Cap()
+			} else {
+				n = argVal.Len()
+			}
+		}
+		obj := Object{
+			Value: reflect.ValueOf(n),
+			Typ:   typ,
+		}
+		return []Object{obj}
+	case "close", "copy", "delete", "panic", "print", "println", "recover":
+		argObjs := env.evalExprs(callExpr.Args)
+		if async {
+			go env.callStmtBuiltin(callExpr, copyObjs(argObjs))
+			return nil
+		}
+		return env.callStmtBuiltin(callExpr, argObjs)
 	case "complex":
-		log.Fatal("complex function not implemented yet")
-	case "len":
-		log.Fatal("len function not implemented yet")
+		argObjs := env.evalExprs(callExpr.Args)
+		realVal := getTypedObject(argObjs[0]).Value.(reflect.Value)
+		imagVal := getTypedObject(argObjs[1]).Value.(reflect.Value)
+		rtyp, _ := getReflectType(env.interp.typeMap, typ)
+		resultVal := reflect.New(rtyp).Elem()
+		resultVal.SetComplex(complex(realVal.Float(), imagVal.Float()))
+		obj := Object{
+			Value: resultVal,
+			Typ:   typ,
+		}
+		return []Object{obj}
+	case "imag", "real":
+		argObj := getTypedObject(env.Eval(callExpr.Args[0])[0])
+		c := argObj.Value.(reflect.Value).Complex()
+		rtyp, _ := getReflectType(env.interp.typeMap, typ)
+		resultVal := reflect.New(rtyp).Elem()
+		if builtinName == "real" {
+			resultVal.SetFloat(real(c))
+		} else {
+			resultVal.SetFloat(imag(c))
+		}
+		obj := Object{
+			Value: resultVal,
+			Typ:   typ,
+		}
+		return []Object{obj}
 	case "make":
 		obj := env.evalMake(callExpr.Args)
 		return []Object{obj}
 	case "new":
-		log.Fatal("new function not implemented yet")
+		elemTyp := env.info.Types[callExpr.Args[0]].Type
+		elemRtyp, _ := getReflectType(env.interp.typeMap, elemTyp)
+		if elemRtyp == nil {
+			log.Fatal("Failed to get reflect.Type to allocate:", elemTyp)
+		}
+		_, sim := getReflectType(env.interp.typeMap, typ)
+		obj := Object{
+			Value: reflect.New(elemRtyp),
+			Typ:   typ,
+			Sim:   sim,
+		}
+		return []Object{obj}
+	default:
+		log.Fatalf("builtin function %s not implemented yet", builtinName)
+	}
+	return nil
+}
+
+// evalAppend implements the append builtin function. With an ellipsis, the
+// second argument is a slice (or a string, for a slice of bytes) whose
+// elements are appended. The result has type typ, the type of the first
+// argument.
+func (env *environ) evalAppend(argObjs []Object, ellipsis bool, typ types.Type) Object {
+	rtyp, sim := getReflectType(env.interp.typeMap, typ)
+	if rtyp == nil {
+		log.Fatal("Failed to get reflect.Type of slice to append to:", typ)
+	}
+	sliceVal, ok := argObjs[0].Value.(reflect.Value)
+	if !ok {
+		// Must be untyped nil
+		sliceVal = reflect.Zero(rtyp)
+	}
+
+	if ellipsis {
+		xsVal, ok := argObjs[1].Value.(reflect.Value)
+		if !ok {
+			// Untyped nil, or an untyped string constant
+			if ev, isConst := argObjs[1].Value.(exact.Value); isConst {
+				xsVal = reflect.ValueOf(exact.StringVal(ev))
+			} else {
+				xsVal = reflect.Zero(rtyp)
+			}
+		}
+		if xsVal.Kind() == reflect.String {
+			xsVal = reflect.ValueOf([]byte(xsVal.String()))
+		}
+		sliceVal = reflect.AppendSlice(sliceVal, xsVal)
+	} else {
+		elemVals := make([]reflect.Value, len(argObjs)-1)
+		for i, argObj := range argObjs[1:] {
+			elemObj := Object{Value: reflect.New(rtyp.Elem()).Elem()}
+			env.assignObj(elemObj, argObj)
+			elemVals[i] = elemObj.Value.(reflect.Value)
+		}
+		sliceVal = reflect.Append(sliceVal, elemVals...)
+	}
+	return Object{
+		Value: sliceVal,
+		Typ:   typ,
+		Sim:   sim,
+	}
+}
+
+// deferBuiltinCall evaluates the arguments of a deferred builtin call, and
+// defers the call itself until the current frame finishes.
+func (env *environ) deferBuiltinCall(callExpr *ast.CallExpr) {
+	argObjs := copyObjs(env.evalExprs(callExpr.Args))
+	env.getFrame().deferCall(func() {
+		env.callStmtBuiltin(callExpr, argObjs)
+	})
+}
+
+// callStmtBuiltin calls one of the builtin functions that are allowed in statement
+// context, and so in "go" and "defer" statements, on already evaluated arguments.
+func (env *environ) callStmtBuiltin(callExpr *ast.CallExpr, argObjs []Object) []Object {
+	builtinName := callExpr.Fun.(*ast.Ident).Name
+	switch builtinName {
+	case "close":
+		argObjs[0].Value.(reflect.Value).Close()
+	case "copy":
+		// The source may be a string if the destination is a slice of bytes
+		dstVal := argObjs[0].Value.(reflect.Value)
+		srcVal, ok := argObjs[1].Value.(reflect.Value)
+		if !ok {
+			srcVal = reflect.ValueOf(exact.StringVal(argObjs[1].Value.(exact.Value)))
+		}
+		n := reflect.Copy(dstVal, srcVal)
+		return []Object{{
+			Value: reflect.ValueOf(n),
+			Typ:   env.info.TypeOf(callExpr),
+		}}
+	case "delete":
+		mapVal := argObjs[0].Value.(reflect.Value)
+		keyObj := Object{Value: reflect.New(mapVal.Type().Key()).Elem()}
+		env.assignObj(keyObj, argObjs[1])
+		// Setting the zero Value deletes the key. Keys are stored without boxes.
+		mapVal.SetMapIndex(unboxed(keyObj.Value.(reflect.Value)), reflect.Value{})
 	case "panic":
-		log.Fatal("panic function not implemented yet")
-	case "print":
-		// Just forward to fmt.Print
-		fun := reflect.ValueOf(fmt.Print)
-		argObjs := env.evalFuncArgs(callExpr.Args)
-		if async {
-			go callFunWithObjs(fun, argObjs)
-		} else {
-			callFunWithObjs(fun, argObjs)
+		// The argument is converted to interface{}, so untyped constants get their default type
+		var p interface{}
+		if argObjs[0].Value != nil {
+			pObj := env.interp.proxyFor(getTypedObject(argObjs[0]), emptyIfaceType)
+			p = pObj.Value.(reflect.Value).Interface()
 		}
-	case "println":
-		// Just forward to fmt.Println
-		fun := reflect.ValueOf(fmt.Println)
-		argObjs := env.evalFuncArgs(callExpr.Args)
-		if async {
-			go callFunWithObjs(fun, argObjs)
+		panic(p)
+	case "print", "println":
+		// Just forward to fmt.Print or fmt.Println
+		args := make([]interface{}, len(argObjs))
+		for i, argObj := range argObjs {
+			if argObj.Value != nil {
+				args[i] = unboxed(getTypedObject(argObj).Value.(reflect.Value)).Interface()
+			}
+		}
+		if builtinName == "println" {
+			fmt.Println(args...)
 		} else {
-			callFunWithObjs(fun, argObjs)
+			fmt.Print(args...)
 		}
-	case "real":
-		log.Fatal("real function not implemented yet")
 	case "recover":
-		log.Fatal("recover function not implemented yet")
+		// Wrap the result in a variable, so it has type interface{} even if it's non-nil
+		p := env.recover()
+		resultTyp := env.info.TypeOf(callExpr)
+		resultVal := reflect.New(reflect.TypeOf(&p).Elem()).Elem()
+		if p != nil {
+			resultVal.Set(reflect.ValueOf(p))
+		}
+		return []Object{{
+			Value: resultVal,
+			Typ:   resultTyp,
+		}}
 	default:
-		log.Fatalf("builtin function %s not implemented yet", builtinName)
+		log.Fatalf("builtin function %s not allowed in statement context", builtinName)
 	}
-	return results
+	return nil
 }
 
+// evalMake implements the make builtin function. The sizes may be untyped
+// constants, and a negative size causes a run-time panic.
 func (env *environ) evalMake(argExprs []ast.Expr) Object {
-	// TODO: not finished!
 	typeExpr := argExprs[0]
 	typ := env.info.Types[typeExpr].Type
 	rtyp, sim := getReflectType(env.interp.typeMap, typ)
 	if rtyp == nil {
 		log.Fatal("Failed to get reflect.Type to make")
 	}
+	var sizes []int
+	if len(argExprs) > 1 {
+		for _, argObj := range env.evalExprs(argExprs[1:]) {
+			sizes = append(sizes, getInt(argObj))
+		}
+	}
+
+	var val reflect.Value
 	switch rtyp.Kind() {
 	case reflect.Chan:
 		buffer := 0
-		if len(argExprs) > 1 {
-			args := env.evalFuncArgs(argExprs[1:])
-			buffer = int(args[0].Value.(reflect.Value).Int())
+		if len(sizes) > 0 {
+			buffer = sizes[0]
 		}
-		chanVal := reflect.MakeChan(rtyp, buffer)
-		return Object{
-			Value: chanVal,
-			Typ:   typ,
-			Sim:   sim,
+		if buffer < 0 {
+			panic(runtimeError("makechan: size out of range"))
 		}
+		val = reflect.MakeChan(rtyp, buffer)
 	case reflect.Map:
-		// We are forced to ignore a length if given, since the reflect package
-		// does not provide any way to specify it.
-		mapVal := reflect.MakeMap(rtyp)
-		return Object{
-			Value: mapVal,
-			Typ:   typ,
-			Sim:   sim,
+		// The size is only a hint, so a negative one is ignored
+		size := 0
+		if len(sizes) > 0 && sizes[0] > 0 {
+			size = sizes[0]
 		}
+		val = reflect.MakeMapWithSize(rtyp, size)
 	case reflect.Slice:
-		args := env.evalFuncArgs(argExprs[1:])
-		sliceLen := int(args[0].Value.(reflect.Value).Int())
+		sliceLen := sizes[0]
 		sliceCap := sliceLen
-		if len(args) > 1 {
-			sliceCap = int(args[1].Value.(reflect.Value).Int())
+		if len(sizes) > 1 {
+			sliceCap = sizes[1]
 		}
-		sliceVal := reflect.MakeSlice(rtyp, sliceLen, sliceCap)
-		return Object{
-			Value: sliceVal,
-			Typ:   typ,
-			Sim:   sim,
+		if sliceLen < 0 {
+			panic(runtimeError("makeslice: len out of range"))
+		}
+		if sliceCap < sliceLen {
+			panic(runtimeError("makeslice: cap out of range"))
 		}
+		val = reflect.MakeSlice(rtyp, sliceLen, sliceCap)
 	default:
 		log.Fatal("make function called with unexpected type")
 	}
 	return Object{
-		Value: nil,
+		Value: val,
 		Typ:   typ,
 		Sim:   sim,
 	}
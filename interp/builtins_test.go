@@ -0,0 +1,52 @@
+package interp
+
+import "testing"
+
+func TestBuiltins(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "append, len and cap",
+			inputs: []string{
+				"var xs []int",
+				"xs = append(xs, 1, 2)\nys := append(xs, xs...)",
+				"bs := append([]byte(\"ab\"), \"cd\"...)",
+				"fmt.Println(xs, ys, len(ys), cap(xs) >= 2, string(bs))",
+				"var p *[4]int",
+				"fmt.Println(len(p), len(\"héllo\"), len(map[int]int{1: 1}))",
+			},
+			want: "[1 2] [1 2 1 2] 4 true abcd\n4 6 1\n",
+		},
+		{
+			name: "copy and delete",
+			inputs: []string{
+				"xs := []int{1, 2, 3}\nys := make([]int, 2)",
+				"n := copy(ys, xs)",
+				`m := map[string]int{"a": 1, "b": 2}`,
+				`delete(m, "a")`,
+				"fmt.Println(n, ys, m)",
+			},
+			want: "2 [1 2] map[b:2]\n",
+		},
+		{
+			name: "new and make",
+			inputs: []string{
+				"p := new(int)\n*p = 3",
+				"s := make([]string, 1, 5)\nc := make(chan int, 1)",
+				"c <- *p",
+				"fmt.Println(*p, len(s), cap(s), <-c)",
+				"n := -1",
+				"fmt.Println(make([]int, n))",
+			},
+			want: "3 1 5 3\nerror: panic: runtime error: makeslice: len out of range\n",
+		},
+		{
+			name: "complex numbers",
+			inputs: []string{
+				"x, y := 1.5, 2.0",
+				"c := complex(x, y)",
+				"fmt.Println(c, real(c), imag(c))",
+			},
+			want: "(1.5+2i) 1.5 2\n",
+		},
+	})
+}
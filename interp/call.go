@@ -2,6 +2,7 @@ package interp
 
 import (
 	"go/ast"
+	"go/token"
 	"reflect"
 
 	"golang.org/x/tools/go/types"
@@ -48,14 +49,75 @@ func (env *environ) getCallExprKind(callExpr *ast.CallExpr) callExprKind {
 	return kindFromSubExpr(callExpr.Fun)
 }
 
-func (env *environ) evalFuncArgs(argExprs []ast.Expr) []Object {
-	return env.evalExprs(argExprs)
+// evalFuncArgs evaluates the arguments of a function call. If the function is
+// variadic, the arguments for its final parameter are put in a new slice, unless
+// they're already given as a slice, as in f(xs...). Either way, functions are
+// always called with one argument per parameter. A native function is given
+// the values in boxes, rather than the boxes.
+func (env *environ) evalFuncArgs(callExpr *ast.CallExpr) []Object {
+	argObjs := env.evalExprs(callExpr.Args)
+	sig := env.info.TypeOf(callExpr.Fun).Underlying().(*types.Signature)
+	n := sig.Params().Len()
+	if sig.Variadic() && callExpr.Ellipsis == token.NoPos {
+		n--
+	}
+	for i, argObj := range argObjs[:n] {
+		// Arguments of interface types from imported packages may need a proxy
+		if rtyp, _ := getReflectType(env.interp.typeMap, sig.Params().At(i).Type()); rtyp != nil {
+			argObjs[i] = env.interp.proxyFor(argObj, rtyp)
+		}
+	}
+	if n < sig.Params().Len() {
+		sliceObj := getObjectOfType(env.interp.typeMap, sig.Params().At(n).Type())
+		if len(argObjs) > n {
+			// With no arguments for it, the final parameter is nil
+			sliceVal := sliceObj.Value.(reflect.Value)
+			sliceVal.Set(reflect.MakeSlice(sliceVal.Type(), len(argObjs)-n, len(argObjs)-n))
+			for i, argObj := range argObjs[n:] {
+				env.assignObj(Object{Value: sliceVal.Index(i)}, argObj)
+			}
+		}
+		argObjs = append(argObjs[:n:n], sliceObj)
+	}
+	if env.isNativeCallee(callExpr.Fun) {
+		for i, argObj := range argObjs {
+			if argVal, ok := argObj.Value.(reflect.Value); ok {
+				argObjs[i].Value = unboxed(argVal)
+			}
+		}
+	}
+	return argObjs
+}
+
+// isNativeCallee reports whether fun is known to be a native function before
+// it's called: a function of an imported package, or a method of a type from
+// one. An interface method isn't, since the dynamic value may be a proxy.
+func (env *environ) isNativeCallee(fun ast.Expr) bool {
+	var obj types.Object
+	switch fun := fun.(type) {
+	case *ast.ParenExpr:
+		return env.isNativeCallee(fun.X)
+	case *ast.Ident:
+		obj = env.info.Uses[fun]
+	case *ast.SelectorExpr:
+		obj = env.info.Uses[fun.Sel]
+	}
+	f, ok := obj.(*types.Func)
+	if !ok || f.Pkg() == nil || f.Pkg().Path() == "" {
+		return false
+	}
+	if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+		_, isInterface := recv.Type().Underlying().(*types.Interface)
+		return !isInterface
+	}
+	return true
 }
 
 // callFunWithObjs calls the given function on the arguments given as a slice of Object.
 // It first converts the arguments to a slice of reflect.Value. It assumes that any Object
 // in the given slice whose Value field is not a reflect.Value is an untyped nil, which
-// should always be true in practice.
+// should always be true in practice. The final argument of a variadic function
+// must be a slice, as given by evalFuncArgs.
 func callFunWithObjs(fun reflect.Value, argObjs []Object) []reflect.Value {
 	argVals := make([]reflect.Value, len(argObjs))
 	funType := fun.Type()
@@ -63,52 +125,87 @@ func callFunWithObjs(fun reflect.Value, argObjs []Object) []reflect.Value {
 		argVal, ok := argObj.Value.(reflect.Value)
 		if !ok {
 			// Must be untyped nil. Use zero value of type instead
-			var rtyp reflect.Type
-			if funType.IsVariadic() && i == funType.NumIn()-1 {
-				rtyp = funType.In(i).Elem()
-			} else {
-				rtyp = fun.Type().In(i)
-			}
-			argVal = reflect.Zero(rtyp)
+			argVal = reflect.Zero(funType.In(i))
 		}
 		argVals[i] = argVal
 	}
+	if funType.IsVariadic() {
+		return fun.CallSlice(argVals)
+	}
 	return fun.Call(argVals)
 }
 
+// copyObjs returns copies of the given objects whose values no longer share
+// storage with any variable. This is used for calls that happen later than their
+// function value and arguments are evaluated, as in "go" and "defer" statements.
+func copyObjs(objs []Object) []Object {
+	copies := make([]Object, len(objs))
+	for i, obj := range objs {
+		copies[i] = obj
+		if val, ok := obj.Value.(reflect.Value); ok && val.CanAddr() {
+			valCopy := reflect.New(val.Type()).Elem()
+			valCopy.Set(val)
+			copies[i].Value = valCopy
+		}
+	}
+	return copies
+}
+
 func (env *environ) evalFuncCall(callExpr *ast.CallExpr, async bool) []Object {
 	funObj := env.Eval(callExpr.Fun)[0]
-	fun := funObj.Value.(reflect.Value)
+	argObjs := env.evalFuncArgs(callExpr)
+	if async {
+		funObj = copyObjs([]Object{funObj})[0]
+		go callFunObj(funObj, copyObjs(argObjs))
+		return nil
+	}
+	return callFunObj(funObj, argObjs)
+}
 
-	argObjs := env.evalFuncArgs(callExpr.Args)
+// deferFuncCall evaluates the function value and arguments of a deferred call,
+// and defers the call itself until the current frame finishes. A deferred
+// function literal, or function declared in the session, is told the frame
+// that defers it, so it may recover from the frame's panic.
+func (env *environ) deferFuncCall(callExpr *ast.CallExpr) {
+	fr := env.getFrame()
+	var funObj Object
+	switch fun := callExpr.Fun.(type) {
+	case *ast.FuncLit:
+		funObj = env.evalFuncLit(fun, fr)
+	case *ast.Ident:
+		if _, isFunc := env.info.Uses[fun].(*types.Func); isFunc {
+			funObj = env.interp.deferredFuncs[fun.Name](fr)
+		} else {
+			funObj = copyObjs(env.Eval(fun))[0]
+		}
+	default:
+		funObj = copyObjs(env.Eval(fun))[0]
+	}
+	argObjs := copyObjs(env.evalFuncArgs(callExpr))
+	fr.deferCall(func() {
+		callFunObj(funObj, argObjs)
+	})
+}
+
+// callFunObj calls the function held in funObj, whether simulated or not, on the
+// given arguments.
+func callFunObj(funObj Object, argObjs []Object) []Object {
+	fun := funObj.Value.(reflect.Value)
 	if funObj.Sim {
 		// Call by actually calling it
 		funVal := fun.Interface().(func([]Object) []Object)
-		if !async {
-			results := funVal(argObjs)
-			return results
-		} else {
-			go funVal(argObjs)
-			return nil
-		}
-	} else {
-		// Now call the function on the args
-		if !async {
-			resultVals := callFunWithObjs(fun, argObjs)
+		return funVal(argObjs)
+	}
+	// Now call the function on the args
+	resultVals := callFunWithObjs(fun, argObjs)
 
-			// Wrap the output values in Objects
-			results := make([]Object, len(resultVals))
-			for i, resVal := range resultVals {
-				results[i] = Object{
-					Value: resVal,
-					Typ:   funObj.Typ.Underlying().(*types.Signature).Results().At(i).Type(),
-				}
-			}
-			return results
-		} else {
-			go callFunWithObjs(fun, argObjs)
-			return nil
+	// Wrap the output values in Objects
+	results := make([]Object, len(resultVals))
+	for i, resVal := range resultVals {
+		results[i] = Object{
+			Value: resVal,
+			Typ:   funObj.Typ.Underlying().(*types.Signature).Results().At(i).Type(),
 		}
-
 	}
+	return results
 }
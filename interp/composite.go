@@ -0,0 +1,111 @@
+package interp
+
+import (
+	"go/ast"
+	"log"
+	"reflect"
+
+	"golang.org/x/tools/go/exact"
+	"golang.org/x/tools/go/types"
+)
+
+// evalCompositeLit evaluates a composite literal of type typ. The value is a
+// new variable, so &T{...} can take its address. An element of a slice, array
+// or map literal may elide its type, in which case the type checker gives it
+// the element type. If that's a pointer type *T, the element stands for &T{...}.
+func (env *environ) evalCompositeLit(e *ast.CompositeLit, typ types.Type) Object {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok && e.Type == nil {
+		elemObj := env.evalCompositeLit(e, ptr.Elem())
+		return Object{
+			Value: elemObj.Value.(reflect.Value).Addr(),
+			Typ:   typ,
+		}
+	}
+
+	rtyp, sim := getReflectType(env.interp.typeMap, typ)
+	if rtyp == nil {
+		log.Fatal("Failed to obtain reflect.Type to represent type:", typ)
+	}
+	val := reflect.New(rtyp).Elem()
+
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		for i, elt := range e.Elts {
+			valExpr := elt
+			fieldIndex := i
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				// The key is the name of a field
+				valExpr = kv.Value
+				name := kv.Key.(*ast.Ident).Name
+				for j := 0; j < t.NumFields(); j++ {
+					if t.Field(j).Name() == name {
+						fieldIndex = j
+						break
+					}
+				}
+			}
+			fieldObj := Object{Value: fieldByIndex(val, []int{fieldIndex})}
+			env.assignObj(fieldObj, env.Eval(valExpr)[0])
+		}
+	case *types.Array:
+		env.setIndexedElts(val, e.Elts)
+	case *types.Slice:
+		n := 0
+		for _, index := range env.eltIndices(e.Elts) {
+			if index >= n {
+				n = index + 1
+			}
+		}
+		val.Set(reflect.MakeSlice(rtyp, n, n))
+		env.setIndexedElts(val, e.Elts)
+	case *types.Map:
+		val.Set(reflect.MakeMap(rtyp))
+		for _, elt := range e.Elts {
+			kv := elt.(*ast.KeyValueExpr)
+			keyObj := Object{Value: reflect.New(rtyp.Key()).Elem()}
+			env.assignObj(keyObj, env.Eval(kv.Key)[0])
+			elemObj := Object{Value: reflect.New(rtyp.Elem()).Elem()}
+			env.assignObj(elemObj, env.Eval(kv.Value)[0])
+			// A box is compared by its address, so keys are stored without one
+			val.SetMapIndex(unboxed(keyObj.Value.(reflect.Value)), elemObj.Value.(reflect.Value))
+		}
+	default:
+		log.Fatalf("Unexpected composite literal type: %v", typ)
+	}
+
+	return Object{
+		Value: val,
+		Typ:   typ,
+		Sim:   sim,
+	}
+}
+
+// eltIndices returns the index of each element of a slice or array literal.
+// An element without a key (a constant index) comes right after the previous
+// element, or at index 0 if it's the first element.
+func (env *environ) eltIndices(elts []ast.Expr) []int {
+	indices := make([]int, len(elts))
+	index := 0
+	for i, elt := range elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			i64, _ := exact.Int64Val(env.info.Types[kv.Key].Value)
+			index = int(i64)
+		}
+		indices[i] = index
+		index++
+	}
+	return indices
+}
+
+// setIndexedElts sets the elements of val, a slice or array long enough to hold
+// them, to the values of the elements of a slice or array literal.
+func (env *environ) setIndexedElts(val reflect.Value, elts []ast.Expr) {
+	for i, index := range env.eltIndices(elts) {
+		valExpr := elts[i]
+		if kv, ok := valExpr.(*ast.KeyValueExpr); ok {
+			valExpr = kv.Value
+		}
+		elemObj := Object{Value: val.Index(index)}
+		env.assignObj(elemObj, env.Eval(valExpr)[0])
+	}
+}
@@ -0,0 +1,68 @@
+package interp
+
+import "testing"
+
+func TestCompositeLit(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "slices and maps",
+			inputs: []string{
+				"xs := []int{1, 2, 3}",
+				`m := map[string]int{"a": 1, "b": 2}`,
+				`fmt.Println(xs, m, m["b"])`,
+				"ys := []int{5: 1, 2, 1: 7}",
+				"fmt.Println(ys)",
+			},
+			want: "[1 2 3] map[a:1 b:2] 2\n[0 7 0 0 0 1 2]\n",
+		},
+		{
+			name: "structs",
+			inputs: []string{
+				"type Point struct{ X, Y int }",
+				"p := Point{1, 2}\nq := Point{Y: 5}",
+				"pp := &Point{X: 3}\npp.Y = 4",
+				`fmt.Printf("%+v %+v %+v\n", p, q, *pp)`,
+			},
+			want: "{X:1 Y:2} {X:0 Y:5} {X:3 Y:4}\n",
+		},
+		{
+			name: "elided types",
+			inputs: []string{
+				"type Point struct{ X, Y int }",
+				"ps := []Point{{1, 2}, {X: 3}}",
+				"pps := []*Point{{5, 6}}",
+				`m := map[string][]int{"a": {1, 2}}`,
+				"grid := [][]int{{1}, {2, 3}}",
+				"fmt.Println(ps, pps[0].Y, m, grid)",
+			},
+			want: "[{1 2} {3 0}] 6 map[a:[1 2]] [[1] [2 3]]\n",
+		},
+		{
+			name: "arrays",
+			inputs: []string{
+				"a := [3]int{1, 2, 3}",
+				"b := [...]string{2: \"c\", 0: \"a\"}",
+				"fmt.Println(a, b)",
+			},
+			want: "[1 2 3] [a  c]\n",
+		},
+		{
+			name: "imported struct type",
+			inputs: []string{
+				"p := image.Point{Y: 2}",
+				"ps := []image.Point{{1, 2}, image.Pt(3, 4)}",
+				"fmt.Println(p, ps, p == ps[0])",
+			},
+			want: "(0,2) [(1,2) (3,4)] false\n",
+		},
+		{
+			name: "recursive struct",
+			inputs: []string{
+				"type Node struct{ V int; Next *Node }",
+				"n := Node{1, &Node{V: 2}}",
+				"fmt.Println(n.V, n.Next.V, n.Next.Next == nil)",
+			},
+			want: "1 2 true\n",
+		},
+	})
+}
@@ -2,22 +2,26 @@ package interp
 
 import (
 	"go/ast"
+	"go/token"
 	"log"
 	"reflect"
+
+	"golang.org/x/tools/go/types"
 )
 
 func getSettableZeroVal(typ reflect.Type) reflect.Value {
-	if typ.Kind() != reflect.Array {
-		return reflect.New(typ).Elem()
-	}
-	log.Fatal("getSettableZeroVal: array types not implemented yet")
-	return reflect.Value{}
+	return reflect.New(typ).Elem()
 }
 
 func (env *environ) getDeclVars(exprs []ast.Expr) []Object {
 	lhs := make([]Object, len(exprs))
 	for i, expr := range exprs {
 		ident := expr.(*ast.Ident)
+		if ident.Name == "_" {
+			// Nothing is declared for the blank identifier. Leave the zero Object
+			// in place so that assignObj discards whatever is assigned to it.
+			continue
+		}
 		identDef := env.info.Defs[ident]
 		if identDef == nil || identDef.Pos() != ident.Pos() {
 			// Redeclaration: variable already exists in current scope. Look up the object.
@@ -47,3 +51,76 @@ func (env *environ) getDeclVars(exprs []ast.Expr) []Object {
 	}
 	return lhs
 }
+
+// runDecl runs a var, const, or type declaration inside a function body
+// (including at top level, which is inside a function body, too).
+func (env *environ) runDecl(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.ValueSpec:
+			if decl.Tok == token.VAR {
+				env.declVars(spec)
+			} else {
+				env.declConsts(spec)
+			}
+		case *ast.TypeSpec:
+			// Types only matter to the type checker, which has already resolved
+			// every use of this one, so there's nothing to do but record the name.
+			env.addName(spec.Name.Name)
+		}
+	}
+}
+
+// addName records a name declared in env, if it's not already declared there.
+func (env *environ) addName(name string) {
+	if name == "_" {
+		return
+	}
+	if _, ok := env.lookup(name); !ok {
+		env.names = append(env.names, name)
+	}
+}
+
+// declVars declares the variables in a var spec. The initial values, if any, are
+// evaluated before any of the variables are declared, since they can't refer to them.
+func (env *environ) declVars(spec *ast.ValueSpec) {
+	var rhs []Object
+	if len(spec.Values) > 0 {
+		rhs = env.evalExprs(spec.Values)
+	}
+	for i, ident := range spec.Names {
+		if ident.Name == "_" {
+			continue
+		}
+		var obj Object
+		if rhs != nil {
+			obj = rhs[i]
+		}
+		env.addName(ident.Name)
+		env.addVar(env.info.Defs[ident].(*types.Var), nil, obj)
+	}
+}
+
+// declConsts declares the constants in a const spec. Their values were already
+// computed by the type checker (including any use of iota), so nothing is evaluated.
+func (env *environ) declConsts(spec *ast.ValueSpec) {
+	for _, ident := range spec.Names {
+		if ident.Name == "_" {
+			continue
+		}
+		c := env.info.Defs[ident].(*types.Const)
+		obj := Object{
+			Value: c.Val(),
+			Typ:   c.Type(),
+		}
+		if isTyped(c.Type()) {
+			tv := types.TypeAndValue{
+				Type:  c.Type(),
+				Value: c.Val(),
+			}
+			obj.Value = convertExactToReflect(env.interp.typeMap, tv)
+		}
+		env.addName(ident.Name)
+		env.objs[ident.Name] = obj
+	}
+}
@@ -0,0 +1,60 @@
+package interp
+
+import "testing"
+
+func TestDecl(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "var",
+			inputs: []string{
+				"var x int",
+				"var a, b = 1, \"two\"",
+				"var q, r = func() (int, error) { return 4, nil }()",
+				"var _, w = 1, 2",
+				"var x2 = x + 1",
+				"var err error",
+				"var e interface{} = 5",
+				"fmt.Println(x, a, b, q, r, w, x2, err, e)",
+			},
+			want: "0 1 two 4 <nil> 2 1 <nil> 5\n",
+		},
+		{
+			name: "const with iota",
+			inputs: []string{
+				"const ( A = iota; B; C )",
+				"const F float64 = 2",
+				"var f = F / 4",
+				"fmt.Println(A, B, C, f)",
+			},
+			want: "0 1 2 0.5\n",
+		},
+		{
+			name: "type",
+			inputs: []string{
+				"type ID string",
+				`var id ID = "abc"`,
+				`const K ID = "k"`,
+				"k := K",
+				"fmt.Println(id, k, id+k)",
+			},
+			want: "abc k abck\n",
+		},
+		{
+			name: "in a function body",
+			inputs: []string{
+				"f := func() int { var (m = 3; n int); const z = 7; type P int; var p P = 2; return m + n + z + int(p) }",
+				"fmt.Println(f())",
+			},
+			want: "12\n",
+		},
+		{
+			name: "shadowing with :=",
+			inputs: []string{
+				"x := 1",
+				"f := func() int { x := x + 1; return x }",
+				"fmt.Println(f(), x)",
+			},
+			want: "2 1\n",
+		},
+	})
+}
@@ -14,6 +14,7 @@ type environ struct {
 	parent *environ
 	objs   map[string]Object
 	names  []string
+	frame  *frame // Non-nil only in the outermost environment of a call
 }
 
 func (env *environ) lookup(s string) (Object, bool) {
@@ -42,6 +43,7 @@ func (env *environ) addVar(varInfo *types.Var, typ reflect.Type, obj Object) {
 	}
 	// Create a variable of the right type with the zero value, then set its value from obj
 	newVal := reflect.New(typ).Elem()
+	obj = env.interp.proxyFor(obj, typ)
 	rval, ok := obj.Value.(reflect.Value)
 	if !ok {
 		// Must be untyped nil. Use zero value of type instead
@@ -60,9 +62,13 @@ func (env *environ) dumpScope() (string, int) {
 	lines := []string{}
 	for _, name := range env.names {
 		_, t := env.scope.LookupParent(name)
-		switch t.(type) {
+		switch t := t.(type) {
 		case *types.Var:
 			lines = append(lines, "var "+name+" "+TypeString(t.Type()))
+		case *types.Const:
+			lines = append(lines, "const "+name+" "+TypeString(t.Type())+" = "+t.Val().String())
+		case *types.TypeName:
+			lines = append(lines, "type "+name+" "+TypeString(t.Type().Underlying()))
 		}
 	}
 	if len(lines) == 0 {
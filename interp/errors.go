@@ -0,0 +1,37 @@
+package interp
+
+import "fmt"
+
+// A runtimeError is the value of a panic caused by a run-time error in
+// interpreted code, such as a nil pointer dereference. Like the errors the Go
+// runtime panics with, it implements runtime.Error.
+type runtimeError string
+
+func (e runtimeError) Error() string {
+	return "runtime error: " + string(e)
+}
+
+func (e runtimeError) RuntimeError() {}
+
+// An unsupportedError is the value of a panic caused by valid input that the
+// interpreter can't handle. Run returns it as an error.
+type unsupportedError string
+
+func (e unsupportedError) Error() string {
+	return string(e)
+}
+
+// errNilDeref is the error for dereferencing a nil pointer.
+var errNilDeref = runtimeError("invalid memory address or nil pointer dereference")
+
+// checkIndex checks an index of an array, slice or string of length n, returning
+// the same error the Go runtime would panic with if it's out of range.
+func checkIndex(i, n int) error {
+	if i < 0 {
+		return runtimeError(fmt.Sprintf("index out of range [%d]", i))
+	}
+	if i >= n {
+		return runtimeError(fmt.Sprintf("index out of range [%d] with length %d", i, n))
+	}
+	return nil
+}
@@ -27,6 +27,12 @@ func (env *environ) evalExprs(exprs []ast.Expr) []Object {
 	return objs
 }
 
+// isFieldSelector reports whether expr selects a field of a struct.
+func isFieldSelector(env *environ, expr *ast.SelectorExpr) bool {
+	sel, ok := env.info.Selections[expr]
+	return ok && sel.Kind() == types.FieldVal
+}
+
 func isMapIndexExpr(env *environ, expr ast.Expr) bool {
 	if e, isIndexExpr := expr.(*ast.IndexExpr); isIndexExpr {
 		if _, isMap := env.info.TypeOf(e.X).Underlying().(*types.Map); isMap {
@@ -69,38 +75,12 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 	typ := tv.Type
 	switch e := expr.(type) {
 	case *ast.FuncLit:
-		// TODO: Simulated functions, to interact with each other correctly inside the
-		// interpreter, should not be "func([]reflect.Value)[]reflect.Value" but instead
-		// "func([]Object)[]Object". This is because a simulated function's arguments may
-		// themselves be simulated functions! To handle this case correctly, the implementation
-		// of the function's body needs to know which arguments are simulated, which means
-		// the arguments must be of type Object rather than reflect.Value.
-		//
-		// Unsimulated functions do not have this problem as long as we guarantee that an
-		// unsimulated function type's parameter types will also be unsimulated. We will make
-		// sure that this guarantee holds.
-
-		// TODO: avoid simulating function types when possible
-		rtyp, sim := getReflectType(env.interp.typeMap, typ)
-		if sim {
-			// We must simulate the function type we want to create
-			f := createSimulatedFunc(env, e)
-			rf := reflect.ValueOf(f)
-			obj := Object{
-				Value: rf,
-				Typ:   typ,
-				Sim:   true,
-			}
-			return []Object{obj}
-		}
-		// We can actually create a function of the right type
-		f := createUnsimulatedFunc(env, e, rtyp)
-		obj := Object{
-			Value: f,
-			Typ:   typ,
-		}
-		return []Object{obj}
+		return []Object{env.evalFuncLit(e, nil)}
 
+	case *ast.CompositeLit:
+		return []Object{env.evalCompositeLit(e, typ)}
+	case *ast.SliceExpr:
+		return []Object{env.evalSliceExpr(e, typ)}
 	case *ast.StarExpr:
 		// Because we have a StarExpr at this point in Eval, we know
 		// it is a unary "*" expression rather than a pointer type
@@ -109,7 +89,7 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 		newVal := xVal.Elem()
 		if !newVal.IsValid() {
 			// Nil pointer dereference!
-			panic("goconsole: Nil pointer dereference")
+			panic(errNilDeref)
 		}
 		obj := Object{
 			Value: newVal,
@@ -117,11 +97,13 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 		}
 		return []Object{obj}
 	case *ast.UnaryExpr:
-		// TODO: implement unary expressions
 		switch e.Op {
 		case token.AND:
 			xObj := env.Eval(e.X)[0]
 			xVal := xObj.Value.(reflect.Value)
+			if !xVal.CanAddr() {
+				panic(unsupportedError(fmt.Sprintf("can't take the address of %s, a field that refers back to its struct type", types.ExprString(e.X))))
+			}
 			newVal := xVal.Addr()
 			obj := Object{
 				Value: newVal,
@@ -156,6 +138,20 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 				return []Object{valObj, okObj}
 			}
 			return []Object{valObj}
+		case token.NOT:
+			xObj := env.Eval(e.X)[0]
+			return []Object{newBoolObject(env, !getBool(xObj), typ)}
+		case token.SUB:
+			return []Object{operatorNegate(env, env.Eval(e.X)[0], typ)}
+		case token.ADD:
+			xObj := getTypedObject(env.Eval(e.X)[0])
+			obj := Object{
+				Value: xObj.Value,
+				Typ:   typ,
+			}
+			return []Object{obj}
+		case token.XOR:
+			return []Object{operatorComplement(env, env.Eval(e.X)[0], typ)}
 		default:
 			log.Fatalf("Unary operator %q not implemented", e.Op)
 		}
@@ -173,11 +169,6 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 		//    * if so, value of expr is (val in x) or [ (val in x), true]
 		//    * otherwise, runtime panic or [ zero val of T, false ]
 
-		// types T and V are identical if and only if all of the following are true:
-		//    * values of T are assignable to V,
-		//    * both or neither of T and V are named types (that is, isNamed(T) == isNamed(V)),
-		//    * (T is not a channel type) OR (dir of T == dir of V)
-
 		eTyp := typ
 
 		// If the expression has tuple type, then it's a "comma, ok" type assertion
@@ -191,74 +182,29 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 
 		obj := env.Eval(e.X)[0]
 		objVal := obj.Value.(reflect.Value)
-		dynamicVal := objVal.Elem()
-		dynamicRtyp := dynamicVal.Type()
 
 		var resultObj Object
-		var assertSuccess bool
-
-		switch typ.Underlying().(type) {
-		case *types.Interface:
-			// assert that dynamic type of obj implements typ
-			// TODO This should be a runtime panic if not
-
-			assertSuccess = objVal.Type().Implements(toRtyp)
-
-			if assertSuccess {
-				resultVal := dynamicVal.Convert(toRtyp)
-				resultObj = Object{
-					Sim:   sim,
-					Typ:   toTyp,
-					Value: resultVal,
-				}
-			} else if !commaOk {
-				// TODO this should be a runtime panic
-				err := fmt.Errorf("interface conversion: interface is %v, not %v", dynamicRtyp, toRtyp)
-				panic(err)
-			} else {
-				resultObj = Object{
-					Sim:   sim,
-					Typ:   toTyp,
-					Value: reflect.Zero(toRtyp),
-				}
+		assertSuccess := env.interp.dynamicTypeMatches(objVal, toTyp, toRtyp)
+		if assertSuccess {
+			resultVal := env.interp.assertedValue(objVal, toRtyp)
+			resultObj = Object{
+				Sim:   sim,
+				Typ:   toTyp,
+				Value: resultVal,
 			}
-
-		default:
-			isNamed := func(t reflect.Type) bool {
-				return len(t.Name()) > 0
+		} else if !commaOk {
+			// TODO this should be a runtime panic
+			dynamicTypStr := "nil"
+			if dynamicVal := objVal.Elem(); dynamicVal.IsValid() {
+				dynamicTypStr = dynamicVal.Type().String()
 			}
-
-			areIdentical := func(t1, t2 reflect.Type) bool {
-				if !t1.AssignableTo(t2) {
-					return false
-				}
-				if isNamed(t1) != isNamed(t2) {
-					return false
-				}
-				if t1.Kind() == reflect.Chan && t1.ChanDir() != t2.ChanDir() {
-					return false
-				}
-				return true
-			}
-
-			assertSuccess = areIdentical(dynamicRtyp, toRtyp)
-			if assertSuccess {
-				resultVal := dynamicVal.Convert(toRtyp)
-				resultObj = Object{
-					Sim:   sim,
-					Typ:   toTyp,
-					Value: resultVal,
-				}
-			} else if !commaOk {
-				// TODO this should be a runtime panic
-				err := fmt.Errorf("interface conversion: interface is %v, not %v", dynamicRtyp, toRtyp)
-				panic(err)
-			} else {
-				resultObj = Object{
-					Sim:   sim,
-					Typ:   toTyp,
-					Value: reflect.Zero(toRtyp),
-				}
+			err := fmt.Errorf("interface conversion: interface is %s, not %v", dynamicTypStr, toRtyp)
+			panic(err)
+		} else {
+			resultObj = Object{
+				Sim:   sim,
+				Typ:   toTyp,
+				Value: reflect.Zero(toRtyp),
 			}
 		}
 
@@ -275,6 +221,9 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 		}
 
 	case *ast.BinaryExpr:
+		if e.Op == token.LAND || e.Op == token.LOR {
+			return []Object{operatorLogical(env, e, typ)}
+		}
 		left := env.Eval(e.X)[0]
 		right := env.Eval(e.Y)[0]
 
@@ -302,26 +251,24 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 			p := obj.Pkg().Name()
 			v, ok := env.interp.pkgs[p].Lookup(obj.Name())
 			if !ok {
-				log.Fatalf("Package object %q not found", obj)
+				// The objects of a Package needn't cover its whole scope
+				panic(unsupportedError(fmt.Sprintf("%s.%s is not available", p, obj.Name())))
 			}
 			return []Object{v}
 		}
 		switch sel.Kind() {
 		case types.FieldVal:
-			xo := env.Eval(e.X)[0]
-			v := xo.Value.(reflect.Value)
-			if sel.Indirect() {
-				v = v.Elem()
-			}
+			rtyp, sim := getReflectType(env.interp.typeMap, sel.Type())
 			obj := Object{
-				Value: v.FieldByIndex(sel.Index()),
+				Value: fieldValue(env.evalField(e), rtyp),
 				Typ:   sel.Type(),
+				Sim:   sim,
 			}
 			return []Object{obj}
 		case types.MethodVal:
-			log.Fatal("Method values not yet implemented:", sel.String())
+			return []Object{env.methodValue(e, sel)}
 		case types.MethodExpr:
-			log.Fatal("Method expressions not yet implemented:", sel.String())
+			return []Object{env.methodExpr(sel)}
 		}
 	case *ast.CallExpr:
 		switch env.getCallExprKind(e) {
@@ -339,6 +286,7 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 			argObj := env.Eval(e.Args[0])[0]
 
 			var val reflect.Value
+			argObj = env.interp.proxyFor(argObj, rtyp)
 			argVal, ok := argObj.Value.(reflect.Value)
 			if !ok {
 				// This means it's a conversion of nil
@@ -364,8 +312,6 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 		// Figure out which type of object we're indexing
 		objTyp := collTyp.Underlying()
 		switch objTyp := objTyp.(type) {
-		case *types.Array:
-			log.Fatal("Array indexing not implemented yet")
 		case *types.Map:
 			keyObj := env.Eval(e.Index)[0]
 			keyVal, ok := keyObj.Value.(reflect.Value)
@@ -413,11 +359,20 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 			}
 			return []Object{resultObj}
 
-		case *types.Slice:
-			indObj := env.Eval(e.Index)[0]
-			ind := int(indObj.Value.(reflect.Value).Int())
+		case *types.Slice, *types.Array, *types.Pointer:
 			sliceObj := env.Eval(e.X)[0]
 			sliceVal := sliceObj.Value.(reflect.Value)
+			ind := getInt(env.Eval(e.Index)[0])
+			if sliceVal.Kind() == reflect.Ptr {
+				// Pointer to array
+				if sliceVal.IsNil() {
+					panic(errNilDeref)
+				}
+				sliceVal = sliceVal.Elem()
+			}
+			if err := checkIndex(ind, sliceVal.Len()); err != nil {
+				panic(err)
+			}
 			resultVal := sliceVal.Index(ind)
 			rtyp, sim := getReflectType(env.interp.typeMap, resultTyp)
 			if rtyp == nil {
@@ -430,9 +385,17 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 			}
 			return []Object{resultObj}
 		case *types.Basic:
-			log.Fatal("String indexing not implemented yet")
-		case *types.Pointer:
-			log.Fatal("Pointer-to-array indexing not implemented yet")
+			// String: the result is a byte, which isn't addressable
+			s := getTypedObject(env.Eval(e.X)[0]).Value.(reflect.Value).String()
+			ind := getInt(env.Eval(e.Index)[0])
+			if err := checkIndex(ind, len(s)); err != nil {
+				panic(err)
+			}
+			resultObj := Object{
+				Value: reflect.ValueOf(s[ind]),
+				Typ:   resultTyp,
+			}
+			return []Object{resultObj}
 		}
 
 		log.Fatalf("Unhandled expression type: %T", e)
@@ -442,6 +405,37 @@ func (env *environ) Eval(expr ast.Expr) []Object {
 	return []Object{}
 }
 
+// dynamicTypeMatches reports whether the dynamic value held in the interface
+// value ifaceVal satisfies a type assertion to toTyp: for a non-interface type,
+// the dynamic type must be identical to toTyp, and for an interface type, the
+// dynamic type must implement it. A nil interface value matches no type.
+func (i *interp) dynamicTypeMatches(ifaceVal reflect.Value, toTyp types.Type, toRtyp reflect.Type) bool {
+	dynamicVal := ifaceVal.Elem()
+	if !dynamicVal.IsValid() {
+		return false
+	}
+	if obj, ok := proxiedObj(ifaceVal); ok {
+		// Compare the type of the value behind the proxy. Either type may be
+		// from an earlier input.
+		objTyp, toTyp := i.currentType(obj.Typ), i.currentType(toTyp)
+		if iface, isInterface := toTyp.Underlying().(*types.Interface); isInterface {
+			return types.Implements(objTyp, iface)
+		}
+		return types.Identical(objTyp, toTyp)
+	}
+	if iface, isInterface := toTyp.Underlying().(*types.Interface); isInterface {
+		if toRtyp == sessionIfaceType {
+			return i.nativeImplements(dynamicVal.Type(), iface)
+		}
+		return dynamicVal.Type().Implements(toRtyp)
+	}
+	if hasSessionType(toTyp) {
+		// A value of such a type would be behind a proxy
+		return false
+	}
+	return dynamicVal.Type() == toRtyp
+}
+
 func isTyped(typ types.Type) bool {
 	t, ok := typ.Underlying().(*types.Basic)
 	return !ok || t.Info()&types.IsUntyped == 0
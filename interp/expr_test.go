@@ -0,0 +1,22 @@
+package interp
+
+import "testing"
+
+func TestPackageObject(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name:   "available",
+			inputs: []string{`fmt.Println(strings.ToUpper("a"), image.Pt(1, 2))`},
+			want:   "A (1,2)\n",
+		},
+		{
+			name: "not available",
+			inputs: []string{
+				"var buf bytes.Buffer",
+				`fmt.Fprint(&buf, "x")`,
+				`fmt.Println("still running")`,
+			},
+			want: "error: fmt.Fprint is not available\nstill running\n",
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package interp
+
+// A frame holds the state of a single call of an interpreted function, or of a
+// single top-level input: the calls deferred so far, and the panic, if any, that
+// is unwinding it. The frame of a deferred call of an interpreted function
+// also knows the frame that deferred it, so that recover can stop its panic.
+type frame struct {
+	deferred  []func()
+	panicking bool
+	panicVal  interface{}
+	deferrer  *frame
+}
+
+// newFrame creates the frame for a call that is about to begin. If the call is
+// a deferred call, deferrer is the frame that deferred it, and otherwise nil.
+func newFrame(deferrer *frame) *frame {
+	return &frame{deferrer: deferrer}
+}
+
+// getFrame returns the frame of the innermost function call (or top-level input)
+// that env belongs to.
+func (env *environ) getFrame() *frame {
+	for e := env; e != nil; e = e.parent {
+		if e.frame != nil {
+			return e.frame
+		}
+	}
+	return nil
+}
+
+// deferCall adds call to the calls to run when the frame finishes.
+func (fr *frame) deferCall(call func()) {
+	fr.deferred = append(fr.deferred, call)
+}
+
+// run runs body, and then runs the deferred calls in LIFO order, whether body
+// finishes normally or panics. If body panics and none of the deferred calls
+// recovers, the panic continues once they have all run.
+func (fr *frame) run(body func()) {
+	returned := false
+	defer func() {
+		if !returned {
+			fr.panicking = true
+			fr.panicVal = recover()
+		}
+		for len(fr.deferred) > 0 {
+			n := len(fr.deferred)
+			call := fr.deferred[n-1]
+			fr.deferred = fr.deferred[:n-1]
+			fr.runDeferredCall(call)
+		}
+		if fr.panicking {
+			panic(fr.panicVal)
+		}
+	}()
+	body()
+	returned = true
+}
+
+// runDeferredCall runs a single deferred call. A panic in the deferred call
+// replaces the panic the frame was unwinding with, if any, and the remaining
+// deferred calls still run.
+func (fr *frame) runDeferredCall(call func()) {
+	returned := false
+	defer func() {
+		if !returned {
+			fr.panicking = true
+			fr.panicVal = recover()
+		}
+	}()
+	call()
+	returned = true
+}
+
+// recover implements the recover builtin function called in env. If env belongs
+// to a deferred call made by a panicking frame, it stops the panic and returns
+// its value. Otherwise, it returns nil. Like in Go, recover has no effect
+// unless the deferred function calls it directly.
+func (env *environ) recover() interface{} {
+	deferrer := env.getFrame().deferrer
+	if deferrer == nil || !deferrer.panicking {
+		return nil
+	}
+	p := deferrer.panicVal
+	deferrer.panicking = false
+	deferrer.panicVal = nil
+	return p
+}
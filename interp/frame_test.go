@@ -0,0 +1,87 @@
+package interp
+
+import "testing"
+
+func TestDefer(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "LIFO order",
+			inputs: []string{
+				`f := func() { defer fmt.Println("first deferred, runs last"); defer fmt.Println("second"); fmt.Println("body") }`,
+				"f()",
+			},
+			want: "body\nsecond\nfirst deferred, runs last\n",
+		},
+		{
+			name: "arguments evaluated at the defer statement",
+			inputs: []string{
+				`f := func() { x := 1; defer fmt.Println("deferred", x); x = 2; fmt.Println("body", x) }`,
+				"f()",
+			},
+			want: "body 2\ndeferred 1\n",
+		},
+		{
+			name: "recover",
+			inputs: []string{
+				`g := func(n int) int { defer func() { fmt.Println("recovered:", recover()) }(); panic("boom"); return n }`,
+				"fmt.Println(g(3))",
+				`fmt.Println("no panic:", recover())`,
+			},
+			want: "recovered: boom\n0\nno panic: <nil>\n",
+		},
+		{
+			name: "panic in a deferred call",
+			inputs: []string{
+				`k := func() { defer func() { fmt.Println("nested", recover()) }(); defer func() { panic("second") }(); panic("first") }`,
+				"k()",
+			},
+			want: "nested second\n",
+		},
+		{
+			name: "recover not called directly by the deferred function",
+			inputs: []string{
+				"indirect := func() interface{} { return recover() }",
+				`m := func() { defer func() { fmt.Println("outer got", recover()) }(); defer func() { fmt.Println("indirect got", indirect()) }(); panic("x") }`,
+				"m()",
+			},
+			want: "indirect got <nil>\nouter got x\n",
+		},
+		{
+			name: "recover in a function literal called by the deferred function",
+			inputs: []string{
+				`m := func() { defer func() { func() { fmt.Println("inner got", recover()) }(); fmt.Println("outer got", recover()) }(); panic("y") }`,
+				"m()",
+			},
+			want: "inner got <nil>\nouter got y\n",
+		},
+		{
+			name: "deferred call of a declared function",
+			inputs: []string{
+				`func handle() { fmt.Println("handled", recover()) }`,
+				`func run() { defer handle(); panic("z") }`,
+				"run()",
+				`fmt.Println("after")`,
+			},
+			want: "handled z\nafter\n",
+		},
+		{
+			name: "recover in a function passed to a deferred call",
+			inputs: []string{
+				"xs := []int{3, 1, 2}",
+				`m := func() { defer func() { fmt.Println("got", recover(), xs) }(); defer sort.Slice(xs, func(i, j int) bool { return recover() == nil && xs[i] < xs[j] }); panic("w") }`,
+				"m()",
+			},
+			want: "got w [1 2 3]\n",
+		},
+		{
+			name: "top level",
+			inputs: []string{
+				`for i := 0; i < 3; i++ { defer fmt.Println("top-level deferred", i) }`,
+				"defer func() { fmt.Println(\"top recover\", recover()) }()\npanic(errors.New(\"top panic\"))",
+				"panic(42)",
+				`fmt.Println("still alive")`,
+			},
+			want: "top-level deferred 2\ntop-level deferred 1\ntop-level deferred 0\ntop recover top panic\nerror: panic: 42\nstill alive\n",
+		},
+	})
+}
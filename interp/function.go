@@ -20,29 +20,73 @@ func getObjectOfType(typeMap *typeutil.Map, typ types.Type) Object {
 	}
 }
 
-func createUnsimulatedFunc(env *environ, funcLit *ast.FuncLit, rtyp reflect.Type) reflect.Value {
-	funcType := env.info.Types[funcLit].Type.(*types.Signature)
-	funcScope := env.info.Scopes[funcLit.Type]
-	funcParams := funcType.Params()
-	funcResults := funcType.Results()
-
-	closureEnv := environ{
+// newClosureEnv makes an environment to hold the variables funcLit closes over.
+// We'll use this as the parent environment of calls instead of env.
+// That way, if the user rebinds the names of variables that this function
+// closes over, the function will continue referencing the old variables.
+func newClosureEnv(env *environ, funcLit *ast.FuncLit) *environ {
+	closureEnv := &environ{
 		info:   env.info,
 		interp: env.interp,
 		scope:  env.scope,
-		parent: env.parent,
+		parent: env.interp.pkgEnv,
 		objs:   map[string]Object{},
 	}
-	vis := newVisitor(env, &closureEnv, funcLit)
+	vis := newVisitor(env, closureEnv, funcLit)
 	ast.Walk(vis, funcLit)
+	return closureEnv
+}
+
+// evalFuncLit evaluates a function literal. If the function is made for a
+// single deferred call, deferrer is the frame that defers it, and otherwise nil.
+func (env *environ) evalFuncLit(funcLit *ast.FuncLit, deferrer *frame) Object {
+	// TODO: Simulated functions, to interact with each other correctly inside the
+	// interpreter, should not be "func([]reflect.Value)[]reflect.Value" but instead
+	// "func([]Object)[]Object". This is because a simulated function's arguments may
+	// themselves be simulated functions! To handle this case correctly, the implementation
+	// of the function's body needs to know which arguments are simulated, which means
+	// the arguments must be of type Object rather than reflect.Value.
+	//
+	// Unsimulated functions do not have this problem as long as we guarantee that an
+	// unsimulated function type's parameter types will also be unsimulated. We will make
+	// sure that this guarantee holds.
+
+	// TODO: avoid simulating function types when possible
+	typ := env.info.Types[funcLit].Type
+	rtyp, sim := getReflectType(env.interp.typeMap, typ)
+	if sim {
+		// We must simulate the function type we want to create
+		f := createSimulatedFunc(newClosureEnv(env, funcLit), typ.(*types.Signature), funcLit.Type, funcLit.Body, deferrer)
+		rf := reflect.ValueOf(f)
+		return Object{
+			Value: rf,
+			Typ:   typ,
+			Sim:   true,
+		}
+	}
+	// We can actually create a function of the right type
+	f := createUnsimulatedFunc(newClosureEnv(env, funcLit), typ.(*types.Signature), funcLit.Type, funcLit.Body, rtyp, deferrer)
+	return Object{
+		Value: f,
+		Typ:   typ,
+	}
+}
+
+func createUnsimulatedFunc(closureEnv *environ, sig *types.Signature, funcType *ast.FuncType,
+	body *ast.BlockStmt, rtyp reflect.Type, deferrer *frame) reflect.Value {
+	funcScope := closureEnv.info.Scopes[funcType]
+	funcParams := sig.Params()
+	funcResults := sig.Results()
+
 	funcVal := func(in []reflect.Value) (results []reflect.Value) {
 		// 1) Create new environment that "inherits" from closureEnv
 		funcEnv := &environ{
 			info:   closureEnv.info,
 			interp: closureEnv.interp,
 			scope:  funcScope,
-			parent: &closureEnv,
+			parent: closureEnv,
 			objs:   map[string]Object{},
+			frame:  newFrame(deferrer),
 		}
 
 		// 2) Add parameters to environment with values from `in`
@@ -57,92 +101,118 @@ func createUnsimulatedFunc(env *environ, funcLit *ast.FuncLit, rtyp reflect.Type
 			funcEnv.addVar(param, nil, obj)
 		}
 
-		// 3) If we have named result parameters, add those to the environment with zero value
-		// TODO: add named results
-		var resultObjs []Object
-		if funcResults.Len() > 0 {
-			// TODO: I should probably stick with a []Object so named results will be easier
-			//   Then I can copy the reflect.Value out of each result at the end into a slice
-			//   to actually return from the function.
-			resultObjs = make([]Object, funcResults.Len())
-			results = make([]reflect.Value, funcResults.Len())
-			for i, _ := range results {
-				resultObjs[i] = getObjectOfType(funcEnv.interp.typeMap, funcResults.At(i).Type())
-				results[i] = resultObjs[i].Value.(reflect.Value)
-			}
-
-		}
-
-		// 4) Evaluate the body of the function (topLevel=false)
-		//     Note: If results are returned, handle them
-		stmtRes := funcEnv.runStmt(funcLit.Body, "", false)
-		if res, ok := stmtRes.(returnResult); ok {
-			for i, resObj := range res {
-				assignObj(resultObjs[i], resObj)
+		// 3) Make the results, with zero values. Named results are variables in
+		//    the environment, too.
+		resultObjs := funcEnv.addResults(funcResults)
+
+		// 4) Evaluate the body of the function (topLevel=false), then run the deferred calls
+		//     Note: If results are returned, handle them. The deferred calls may change
+		//     named results, so only take the values of the results afterwards.
+		funcEnv.frame.run(func() {
+			stmtRes := funcEnv.runStmt(body, "", false)
+			if res, ok := stmtRes.(returnResult); ok {
+				funcEnv.setResults(resultObjs, res)
 			}
+		})
+		results = make([]reflect.Value, len(resultObjs))
+		for i, resultObj := range resultObjs {
+			results[i] = resultObj.Value.(reflect.Value)
 		}
 		return
 	}
 	return reflect.MakeFunc(rtyp, funcVal)
 }
 
-func createSimulatedFunc(env *environ, funcLit *ast.FuncLit) func([]Object) []Object {
-	funcType := env.info.Types[funcLit].Type.(*types.Signature)
-	funcScope := env.info.Scopes[funcLit.Type]
-	funcParams := funcType.Params()
-	funcResults := funcType.Results()
-
-	// Make an environment to hold the variables this function closes over.
-	// We'll use this as the parent environment of calls instead of env.
-	// That way, if the user rebinds the names of variables that this function
-	// closes over, the function will continue referencing the old variables.
-	closureEnv := environ{
-		info:   env.info,
-		interp: env.interp,
-		scope:  env.scope,
-		parent: env.parent,
-		objs:   map[string]Object{},
-	}
-	vis := newVisitor(env, &closureEnv, funcLit)
-	ast.Walk(vis, funcLit)
-	return func(in []Object) (results []Object) {
+func createSimulatedFunc(closureEnv *environ, sig *types.Signature, funcType *ast.FuncType,
+	body *ast.BlockStmt, deferrer *frame) func([]Object) []Object {
+	funcScope := closureEnv.info.Scopes[funcType]
+	funcParams := sig.Params()
+	funcResults := sig.Results()
+
+	return func(in []Object) []Object {
 		// 1) Create new environment that "inherits" from closureEnv
 		funcEnv := &environ{
 			info:   closureEnv.info,
 			interp: closureEnv.interp,
 			scope:  funcScope,
-			parent: &closureEnv,
+			parent: closureEnv,
 			objs:   map[string]Object{},
+			frame:  newFrame(deferrer),
 		}
 
-		// 2) Add parameters to environment with values from `in`
+		// 2) Add parameters to environment with values from `in`. A method gets
+		//    its receiver as the first argument.
+		if recv := sig.Recv(); recv != nil {
+			funcEnv.addVar(recv, nil, in[0])
+			in = in[1:]
+		}
 		for i := 0; i < funcParams.Len(); i++ {
 			// Add variable to environment for this param
 			param := funcParams.At(i)
 			funcEnv.addVar(param, nil, in[i])
 		}
 
-		// 3) If we have named result parameters, add those to the environment with zero value
-		// TODO: add named results
-		if funcResults.Len() > 0 {
-			results = make([]Object, funcResults.Len())
-			for i, _ := range results {
-				// Make an Object of the right type with the zero value.
-				// On return with values, we will assign given values to these Objects
-				results[i] = getObjectOfType(funcEnv.interp.typeMap, funcResults.At(i).Type())
+		// 3) Make the results, with zero values. Named results are variables in
+		//    the environment, too.
+		resultObjs := funcEnv.addResults(funcResults)
+
+		// 4) Evaluate the body of the function (topLevel=false), then run the deferred calls
+		//     Note: If results are returned, handle them. The deferred calls may change
+		//     named results, so only take the values of the results afterwards.
+		funcEnv.frame.run(func() {
+			stmtRes := funcEnv.runStmt(body, "", false)
+			if res, ok := stmtRes.(returnResult); ok {
+				funcEnv.setResults(resultObjs, res)
 			}
+		})
+		return copyObjs(resultObjs)
+	}
+}
+
+// addResults makes an Object of the right type with the zero value for each
+// result of a function call. Named results are added to env as variables.
+// On return with values, we will assign given values to these Objects.
+func (env *environ) addResults(results *types.Tuple) []Object {
+	resultObjs := make([]Object, results.Len())
+	for i := range resultObjs {
+		result := results.At(i)
+		resultObjs[i] = getObjectOfType(env.interp.typeMap, result.Type())
+		if name := result.Name(); name != "" && name != "_" {
+			env.objs[name] = resultObjs[i]
 		}
+	}
+	return resultObjs
+}
 
-		// 4) Evaluate the body of the function (topLevel=false)
-		//     Note: If results are returned, handle them
-		stmtRes := funcEnv.runStmt(funcLit.Body, "", false)
-		if res, ok := stmtRes.(returnResult); ok {
-			for i, resObj := range res {
-				assignObj(results[i], resObj)
+// setResults assigns the values given in a return statement to the results.
+// A bare return leaves the named results as they are. The values may be named
+// results themselves, as in "return y, x", so they're all copied first.
+func (env *environ) setResults(resultObjs, values []Object) {
+	values = copyObjs(values)
+	for i, value := range values {
+		env.assignObj(resultObjs[i], value)
+	}
+}
+
+// wrapSimulatedFunc makes a function of type rtyp, which must be the unsimulated
+// type of sig, that calls the simulated function f.
+func wrapSimulatedFunc(f func([]Object) []Object, sig *types.Signature, rtyp reflect.Type) reflect.Value {
+	funcParams := sig.Params()
+	return reflect.MakeFunc(rtyp, func(in []reflect.Value) []reflect.Value {
+		argObjs := make([]Object, len(in))
+		for i, argVal := range in {
+			argObjs[i] = Object{
+				Value: argVal,
+				Typ:   funcParams.At(i).Type(),
 			}
 		}
-		return
-	}
+		resultObjs := f(argObjs)
+		results := make([]reflect.Value, len(resultObjs))
+		for i, resObj := range resultObjs {
+			results[i] = resObj.Value.(reflect.Value)
+		}
+		return results
+	})
 }
 
 type visitor struct {
@@ -163,6 +233,10 @@ func (v *visitor) Visit(node ast.Node) (w ast.Visitor) {
 				// We're done visiting this node
 				return nil
 			}
+			// Neither do package-level functions, which may be redefined later
+			if _, isFunc := obj.(*types.Func); isFunc {
+				return nil
+			}
 			if !(idPos > v.begin && idPos < v.end) {
 				// Add the closed-over variable to newEnv
 				if val, ok := v.oldEnv.lookupParent(id.Name); ok {
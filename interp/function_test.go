@@ -0,0 +1,129 @@
+package interp
+
+import "testing"
+
+func TestNamedResults(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "bare return",
+			inputs: []string{
+				"divmod := func(a, b int) (q, r int) { q = a / b; r = a % b; return }",
+				"fmt.Println(divmod(17, 5))",
+			},
+			want: "3 2\n",
+		},
+		{
+			name: "swapped in return",
+			inputs: []string{
+				"swap := func() (x, y int) { x, y = 1, 2; return y, x }",
+				"fmt.Println(swap())",
+			},
+			want: "2 1\n",
+		},
+		{
+			name: "changed by defer",
+			inputs: []string{
+				"double := func(n int) (r int) { defer func() { r *= 2 }(); return n + 1 }",
+				"fmt.Println(double(4))",
+			},
+			want: "10\n",
+		},
+		{
+			name: "set by recover",
+			inputs: []string{
+				"func safeDiv(a, b int) (q int, err error) {\n" +
+					"\tdefer func() {\n" +
+					"\t\tif e := recover(); e != nil {\n" +
+					"\t\t\terr = fmt.Errorf(\"recovered: %v\", e)\n" +
+					"\t\t}\n" +
+					"\t}()\n" +
+					"\treturn a / b, nil\n" +
+					"}",
+				"fmt.Println(safeDiv(6, 3))",
+				"fmt.Println(safeDiv(1, 0))",
+			},
+			want: "2 <nil>\n0 recovered: runtime error: integer divide by zero\n",
+		},
+		{
+			name: "simulated function",
+			inputs: []string{
+				"apply := func(f func(int) int) (r int) { defer func() { r = -r }(); r = f(3); return }",
+				"fmt.Println(apply(func(n int) int { return n * n }))",
+			},
+			want: "-9\n",
+		},
+	})
+}
+
+func TestVariadic(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "session function",
+			inputs: []string{
+				"func sum(prefix string, ns ...int) string { t := 0; for _, n := range ns { t += n }; return fmt.Sprint(prefix, len(ns), t, ns == nil) }",
+				`fmt.Println(sum("a"))`,
+				`fmt.Println(sum("b", 1, 2, 3))`,
+				"xs := []int{4, 5}",
+				`fmt.Println(sum("c", xs...))`,
+			},
+			want: "a0 0 true\nb3 6 false\nc2 9 false\n",
+		},
+		{
+			name: "function literal and imported function",
+			inputs: []string{
+				"join := func(sep string, parts ...string) string { return strings.Join(parts, sep) }",
+				`args := []interface{}{"x", 1, nil}`,
+				`fmt.Println(join("-", "a", "b"), fmt.Sprint(args...))`,
+				`fmt.Println(nil, 2)`,
+			},
+			want: "a-b x1 <nil>\n<nil> 2\n",
+		},
+		{
+			name: "deferred",
+			inputs: []string{
+				"f := func() { xs := []interface{}{1, 2}; defer fmt.Println(xs...); defer fmt.Println(\"first\", 0) }",
+				"f()",
+			},
+			want: "first 0\n1 2\n",
+		},
+	})
+}
+
+func TestNativeFunc(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "passed to imported functions",
+			inputs: []string{
+				`xs := []string{"bb", "a", "ccc"}`,
+				"sort.Slice(xs, func(i, j int) bool { return len(xs[i]) > len(xs[j]) })",
+				"rot := func(r rune) rune { return r + 1 }",
+				`fmt.Println(xs, strings.Map(rot, "HAL"))`,
+				`fmt.Println(strings.FieldsFunc("a1b22c", func(r rune) bool { return r >= '0' && r <= '9' }))`,
+			},
+			want: "[ccc bb a] IBM\n[a b c]\n",
+		},
+		{
+			name: "declared functions and session types",
+			inputs: []string{
+				"type Point struct{ X, Y int }",
+				"func byX(ps []Point) func(i, j int) bool { return func(i, j int) bool { return ps[i].X < ps[j].X } }",
+				"ps := []Point{{3, 0}, {1, 1}, {2, 2}}",
+				"sort.Slice(ps, byX(ps))",
+				"fmt.Println(ps)",
+			},
+			want: "[{1 1} {2 2} {3 0}]\n",
+		},
+		{
+			name: "types that refer back to themselves",
+			inputs: []string{
+				"type F func(n int) F",
+				"var f F\nf = func(n int) F { fmt.Print(n, \" \"); return f }",
+				"_ = f(1)(2)(3)",
+				"type Node struct{ V int; Visit func(*Node) int }",
+				"n := &Node{V: 4, Visit: func(n *Node) int { return n.V * 2 }}",
+				"fmt.Println(n.Visit(n))",
+			},
+			want: "1 2 3 8\n",
+		},
+	})
+}
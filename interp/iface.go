@@ -0,0 +1,92 @@
+package interp
+
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/tools/go/types"
+)
+
+// sessionIface represents the interface types with methods that are declared in
+// the session, since the reflect package can't create interface types. Any value
+// can be stored in one. A value that isn't itself an interface is stored behind
+// a *Proxy, which knows the value's type, so that its methods can be found and
+// type assertions can compare its type.
+type sessionIface interface{}
+
+var sessionIfaceType = reflect.TypeOf((*sessionIface)(nil)).Elem()
+
+// ifaceMethod returns the method value of method, a method of an interface type
+// declared in the session, for the dynamic value of recvVal.
+func (i *interp) ifaceMethod(recvVal reflect.Value, method *types.Func) Object {
+	if obj, ok := proxiedObj(recvVal); ok {
+		sel := lookupMethod(obj.Typ, method.Name())
+		if sel == nil {
+			panic(unsupportedError(fmt.Sprintf("method %s of %v not found", method.Name(), obj.Typ)))
+		}
+		recvVal := i.methodRecv(obj.Value.(reflect.Value), sel)
+		return i.bindMethod(recvVal, sel.Obj().(*types.Func))
+	}
+
+	// The dynamic value came from a native interface, so it has native methods
+	sig := method.Type().(*types.Signature)
+	methodVal := recvVal.Elem().MethodByName(method.Name())
+	if _, sim := getReflectType(i.typeMap, sig); !sim {
+		return Object{
+			Value: methodVal,
+			Typ:   sig,
+		}
+	}
+	bound := func(in []Object) []Object {
+		return callFunObj(Object{Value: methodVal, Typ: sig}, in)
+	}
+	return Object{
+		Value: reflect.ValueOf(bound),
+		Typ:   sig,
+		Sim:   true,
+	}
+}
+
+// lookupMethod looks up the method of the given name in the method set of typ.
+// Unlike types.MethodSet.Lookup, it finds unexported methods whatever package
+// they're from, since each input is type checked as a new package.
+func lookupMethod(typ types.Type, name string) *types.Selection {
+	mset := types.NewMethodSet(typ)
+	for i := 0; i < mset.Len(); i++ {
+		if sel := mset.At(i); sel.Obj().Name() == name {
+			return sel
+		}
+	}
+	return nil
+}
+
+// nativeImplements reports whether rtyp has each method of iface, with the same
+// signature. It's used for values whose types are only known to the reflect
+// package, so the signatures are compared as reflect types. A native method
+// can't have types declared in the session in its signature.
+func (i *interp) nativeImplements(rtyp reflect.Type, iface *types.Interface) bool {
+	for j := 0; j < iface.NumMethods(); j++ {
+		method := iface.Method(j)
+		m, ok := rtyp.MethodByName(method.Name())
+		if !ok || hasSessionType(method.Type()) {
+			return false
+		}
+		sigRtyp, sim := getReflectType(i.typeMap, method.Type())
+		if sigRtyp == nil || sim {
+			return false
+		}
+		// The type of the method has the receiver as its first parameter
+		in := make([]reflect.Type, m.Type.NumIn()-1)
+		for k := range in {
+			in[k] = m.Type.In(k + 1)
+		}
+		out := make([]reflect.Type, m.Type.NumOut())
+		for k := range out {
+			out[k] = m.Type.Out(k)
+		}
+		if reflect.FuncOf(in, out, m.Type.IsVariadic()) != sigRtyp {
+			return false
+		}
+	}
+	return true
+}
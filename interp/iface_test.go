@@ -0,0 +1,89 @@
+package interp
+
+import "testing"
+
+func TestSessionIface(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "dynamic dispatch",
+			inputs: []string{
+				"type Shape interface{ Area() float64 }",
+				"type Rect struct{ W, H float64 }\nfunc (r Rect) Area() float64 { return r.W * r.H }",
+				"type Square float64\nfunc (s *Square) Area() float64 { return float64(*s * *s) }",
+				"sq := Square(3)",
+				"shapes := []Shape{Rect{2, 3}, &sq}",
+				"total := func(ss []Shape) float64 { t := 0.0; for _, s := range ss { t += s.Area() }; return t }",
+				"fmt.Println(total(shapes))",
+			},
+			want: "15\n",
+		},
+		{
+			name: "native methods",
+			inputs: []string{
+				"type Lener interface{ Len() int }",
+				`var l Lener = bytes.NewBufferString("abcd")`,
+				"fmt.Println(l.Len())",
+			},
+			want: "4\n",
+		},
+		{
+			name: "inline and embedded interfaces",
+			inputs: []string{
+				"type Namer interface{ Name() string }",
+				"type Greeter interface {\n\tNamer\n\tGreet() string\n}",
+				"type En string\nfunc (e En) Name() string { return string(e) }\nfunc (e En) Greet() string { return \"hi \" + string(e) }",
+				"var g Greeter = En(\"bob\")",
+				"var n interface{ Name() string } = g",
+				"fmt.Println(g.Greet(), n.Name())",
+			},
+			want: "hi bob bob\n",
+		},
+		{
+			name: "assertions and switches",
+			inputs: []string{
+				"type Shape interface{ Area() float64 }",
+				"type Rect struct{ W, H float64 }\nfunc (r Rect) Area() float64 { return r.W * r.H }",
+				"var s Shape = Rect{1, 2}",
+				"r, ok := s.(Rect)",
+				"_, isStringer := s.(fmt.Stringer)",
+				"fmt.Println(r.W, ok, isStringer)",
+				"switch v := s.(type) {\ncase fmt.Stringer:\n\tfmt.Println(\"stringer\")\ncase Rect:\n\tfmt.Println(\"rect\", v.H)\n}",
+			},
+			want: "1 true false\nrect 2\n",
+		},
+		{
+			name: "nil and equality",
+			inputs: []string{
+				"type Valuer interface{ Value() int }",
+				"type A int\nfunc (a A) Value() int { return int(a) }",
+				"type B int\nfunc (b B) Value() int { return int(b) }",
+				"var v, w Valuer",
+				"fmt.Println(v == nil)",
+				"v, w = A(1), A(1)",
+				"fmt.Println(v == w, v != nil)",
+				"w = B(1)",
+				"fmt.Println(v == w)",
+				"v = nil",
+				"fmt.Println(v.Value())",
+			},
+			want: "true\ntrue true\nfalse\nerror: panic: runtime error: invalid memory address or nil pointer dereference\n",
+		},
+		{
+			name: "asserted from an empty interface",
+			inputs: []string{
+				"type Shape interface{ Area() float64 }",
+				"type Rect struct{ W, H float64 }\nfunc (r Rect) Area() float64 { return r.W * r.H }",
+				"var x interface{} = Rect{3, 4}",
+				"s, ok := x.(Shape)",
+				"fmt.Println(s.Area(), ok)",
+				"type Lener interface{ Len() int }",
+				"type BadLener interface{ Len() string }",
+				`var y interface{} = bytes.NewBufferString("ab")`,
+				"l, ok := y.(Lener)",
+				"_, bad := y.(BadLener)",
+				"fmt.Println(l.Len(), ok, bad)",
+			},
+			want: "12 true\n2 true false\n",
+		},
+	})
+}
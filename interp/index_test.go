@@ -0,0 +1,58 @@
+package interp
+
+import "testing"
+
+func TestIndex(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "strings",
+			inputs: []string{
+				`s := "héllo"`,
+				"b := s[1]",
+				`fmt.Printf("%T %v %c\n", b, b, s[0])`,
+			},
+			want: "uint8 195 h\n",
+		},
+		{
+			name: "addressable array elements",
+			inputs: []string{
+				"a := [3]int{1, 2, 3}",
+				"p := &a[1]\n*p = 20",
+				"q := &a\nq[2] = 30",
+				"fmt.Println(a, q[1])",
+			},
+			want: "[1 20 30] 20\n",
+		},
+		{
+			name: "swap",
+			inputs: []string{
+				"xs := []int{1, 2, 3}\na := [2]string{\"x\", \"y\"}",
+				"xs[0], xs[2] = xs[2], xs[0]\na[0], a[1] = a[1], a[0]",
+				"fmt.Println(xs, a)",
+			},
+			want: "[3 2 1] [y x]\n",
+		},
+		{
+			name: "out of range",
+			inputs: []string{
+				"xs := []int{1, 2}\ni := -1",
+				"fmt.Println(xs[2])",
+				"fmt.Println(xs[i])",
+				`s := "ab"`,
+				"fmt.Println(s[i+3])",
+			},
+			want: "error: panic: runtime error: index out of range [2] with length 2\n" +
+				"error: panic: runtime error: index out of range [-1]\n" +
+				"error: panic: runtime error: index out of range [2] with length 2\n",
+		},
+		{
+			name: "recovered",
+			inputs: []string{
+				"a := [2]int{}\nn := 5",
+				"f := func() { defer func() { fmt.Println(recover()) }(); a[n] = 1 }",
+				"f()",
+			},
+			want: "runtime error: index out of range [5] with length 2\n",
+		},
+	})
+}
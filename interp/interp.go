@@ -8,6 +8,7 @@ import (
 	"go/scanner"
 	"go/token"
 	"log"
+	"reflect"
 	"strings"
 
 	"golang.org/x/tools/go/types"
@@ -16,12 +17,22 @@ import (
 
 type interp struct {
 	oldSrc       string
+	pkgEnv       *environ
 	topEnv       *environ
 	pkgs         map[string]*Package
 	checker      *checker
 	typeMap      *typeutil.Map
 	stmtLists    []string
 	stmtListLens []int
+	pkgDecls     []pkgDecl
+	pendingDecls []pkgDecl
+	methods      map[string]func([]Object) []Object
+	proxies      map[reflect.Type]ProxyMaker // By native interface type
+	pkg          *types.Package              // As of the latest type check without errors
+
+	// deferredFuncs makes each function declared in the session anew, for a
+	// deferred call, so that it knows the frame that defers it
+	deferredFuncs map[string]func(deferrer *frame) Object
 }
 
 func newInterp(pkgs []*Package, pkgMap map[string]*types.Package, typeMap *typeutil.Map) Interpreter {
@@ -31,15 +42,31 @@ func newInterp(pkgs []*Package, pkgMap map[string]*types.Package, typeMap *typeu
 		pkgObjMap[pkg.Name] = pkg
 	}
 	addBasicTypes(typeMap)
+	proxies := map[reflect.Type]ProxyMaker{}
+	for _, pkg := range pkgs {
+		for typ, makeProxy := range pkg.Proxies {
+			if rtyp, ok := typeMap.At(typ).(reflect.Type); ok {
+				proxies[rtyp] = makeProxy
+			}
+		}
+	}
 	i := &interp{
 		pkgs: pkgObjMap,
-		topEnv: &environ{
+		pkgEnv: &environ{
 			objs: map[string]Object{},
 		},
-		checker: newChecker(pkgs, pkgMap),
-		typeMap: typeMap,
+		checker:       newChecker(pkgs, pkgMap),
+		typeMap:       typeMap,
+		methods:       map[string]func([]Object) []Object{},
+		proxies:       proxies,
+		deferredFuncs: map[string]func(*frame) Object{},
+	}
+	i.pkgEnv.interp = i
+	i.topEnv = &environ{
+		interp: i,
+		parent: i.pkgEnv,
+		objs:   map[string]Object{},
 	}
-	i.topEnv.interp = i
 	return i
 }
 
@@ -70,7 +97,17 @@ func newChecker(pkgs []*Package, pkgMap map[string]*types.Package) *checker {
 	return c
 }
 
-func (i *interp) Run(src string) (bool, error) {
+func (i *interp) Run(src string) (more bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			e, ok := p.(unsupportedError)
+			if !ok {
+				panic(p)
+			}
+			more, err = false, e
+		}
+	}()
+
 	src = strings.TrimSpace(src)
 	if len(src) == 0 {
 		if i.oldSrc == "" {
@@ -84,29 +121,12 @@ func (i *interp) Run(src string) (bool, error) {
 		i.oldSrc = ""
 	}
 
-	// TODO: Only dump out declarations after each input rather than entire history of source.
-	var allSrcBuf bytes.Buffer
-	allSrcBuf.WriteString("package p;import(")
-	for _, pkg := range i.pkgs {
-		fmt.Fprintf(&allSrcBuf, "%q;", pkg.Pkg.Path())
+	if isPkgDecl(src) {
+		return i.runPkgDecls(src)
 	}
-	allSrcBuf.WriteString(");func _(){")
 
-	// Add previous code, one stmtList at a time, each in a nested scope
-	for _, stmtList := range i.stmtLists {
-		allSrcBuf.WriteString(stmtList)
-		allSrcBuf.WriteString("\n{")
-	}
-	// Add current code in the innermost scope and close the scopes
-	allSrcBuf.WriteString(src)
-	allSrcBuf.WriteString("\n")
-	for _ = range i.stmtLists {
-		allSrcBuf.WriteString("}")
-	}
-	allSrcBuf.WriteString("}")
-
-	// Get the source "file" as a string
-	allSrc := allSrcBuf.String()
+	// TODO: Only dump out declarations after each input rather than entire history of source.
+	allSrc := i.buildSrc(i.pkgDecls, src)
 	fileSize := len(allSrc)
 
 	// Parse it
@@ -131,7 +151,7 @@ func (i *interp) Run(src string) (bool, error) {
 		return false, err
 	}
 
-	if len(file.Decls) != 2 {
+	if len(file.Decls) != len(i.pkgDecls)+2 {
 		// The input must have done something strange with braces
 		err := fmt.Errorf("Unexpected '}'")
 		return false, err
@@ -139,7 +159,9 @@ func (i *interp) Run(src string) (bool, error) {
 
 	// Walk down the scopes to the inner statement list, checking that nothing
 	// looks wrong along the way
-	stmtList := file.Decls[len(file.Decls)-1].(*ast.FuncDecl).Body.List
+	undDecl := file.Decls[len(file.Decls)-1].(*ast.FuncDecl)
+	block := undDecl.Body
+	stmtList := block.List
 	for j := range i.stmtLists {
 		if len(stmtList) != i.stmtListLens[j]+1 {
 			// There must be an extra closing brace that escaped our block statement
@@ -151,50 +173,148 @@ func (i *interp) Run(src string) (bool, error) {
 			err := fmt.Errorf("Parse error")
 			return false, err
 		}
+		block = blockStmt
 		stmtList = blockStmt.List
 	}
 	if len(stmtList) == 0 {
 		return false, nil
 	}
 
-	// Clear the type-checker errors and create a struct to hold type info
-	i.checker.errs = i.checker.errs[:0]
-	info := types.Info{
-		Types:      map[ast.Expr]types.TypeAndValue{},
-		Selections: map[*ast.SelectorExpr]*types.Selection{},
-		Defs:       map[*ast.Ident]types.Object{},
-		Uses:       map[*ast.Ident]types.Object{},
-	}
 	// Type check the statement list
-	files := []*ast.File{file}
-	pkg, _ := i.checker.config.Check("", fset, files, &info)
-	if len(i.checker.errs) > 0 {
-		return false, i.checker.errs[0]
+	info, err := i.check(fset, file)
+	if err != nil {
+		return false, err
 	}
 
-	// Walk down the scopes to the inner statement list, checking that nothing
-	// looks wrong along the way
-	pkgScope := pkg.Scope().Child(0)
-	undScope := pkgScope.Child(pkgScope.NumChildren() - 1)
-	currScope := undScope
+	// Walk down the scopes to the inner statement list
+	currScope := info.Scopes[undDecl.Type]
 	for _ = range i.stmtLists {
 		currScope = currScope.Child(currScope.NumChildren() - 1)
 	}
 	// get the scope of the block stmt containing user code
 	i.topEnv.scope = currScope
-	i.topEnv.info = &info
+	i.topEnv.info = info
 
-	// Run each statement in the list
-	for _, stmt := range stmtList {
-		stmtRes := i.topEnv.runStmt(stmt, "", true)
-		if stmtRes != nil {
-			log.Fatal("return from top level not allowed")
-		}
+	// Run each statement in the list, then the calls deferred at top level.
+	// A panic that isn't recovered ends the input with an error.
+	if err := i.runTopLevel(stmtList); err != nil {
+		return false, err
 	}
 
-	// Add current input to the stmtLists slice for next time
-	i.stmtLists = append(i.stmtLists, src)
+	// Add current input to the stmtLists slice for next time. All the inputs
+	// are checked in the same function, so its labels are renamed for a later
+	// input to reuse their names.
+	srcOffset := fset.Position(block.Lbrace).Offset + 1
+	i.stmtLists = append(i.stmtLists, renameLabels(fset, src, srcOffset, stmtList, len(i.stmtLists)))
 	i.stmtListLens = append(i.stmtListLens, len(stmtList))
 
 	return false, nil
 }
+
+// buildSrc returns the source of the file that is type checked for each input.
+// The given declarations are at package level, followed by a function
+// containing the statement lists of previous inputs, one at a time, each in a
+// nested scope, and then src in the innermost scope.
+func (i *interp) buildSrc(pkgDecls []pkgDecl, src string) string {
+	var allSrcBuf bytes.Buffer
+	allSrcBuf.WriteString("package p;import(")
+	for _, pkg := range i.pkgs {
+		fmt.Fprintf(&allSrcBuf, "%q;", pkg.Pkg.Path())
+	}
+	allSrcBuf.WriteString(");")
+
+	for _, decl := range pkgDecls {
+		allSrcBuf.WriteString(decl.src)
+		allSrcBuf.WriteString("\n")
+	}
+	allSrcBuf.WriteString("func _(){")
+
+	// Add previous code, one stmtList at a time, each in a nested scope
+	for _, stmtList := range i.stmtLists {
+		allSrcBuf.WriteString(stmtList)
+		allSrcBuf.WriteString("\n{")
+	}
+	// Add current code in the innermost scope and close the scopes
+	allSrcBuf.WriteString(src)
+	allSrcBuf.WriteString("\n")
+	for _ = range i.stmtLists {
+		allSrcBuf.WriteString("}")
+	}
+	allSrcBuf.WriteString("}")
+
+	return allSrcBuf.String()
+}
+
+// renameLabels returns src, the source of the statements in stmtList starting
+// at srcOffset in the file, with each label given a name unique to input n.
+func renameLabels(fset *token.FileSet, src string, srcOffset int, stmtList []ast.Stmt, n int) string {
+	// The labels are found in the order they appear in the source
+	var labels []*ast.Ident
+	for _, stmt := range stmtList {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.LabeledStmt:
+				labels = append(labels, node.Label)
+			case *ast.BranchStmt:
+				if node.Label != nil {
+					labels = append(labels, node.Label)
+				}
+			}
+			return true
+		})
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, label := range labels {
+		offset := fset.Position(label.Pos()).Offset - srcOffset
+		buf.WriteString(src[last:offset])
+		fmt.Fprintf(&buf, "goconsole_input%d_%s", n, label.Name)
+		last = offset + len(label.Name)
+	}
+	buf.WriteString(src[last:])
+	return buf.String()
+}
+
+// check type checks the file built for an input and returns the type info.
+func (i *interp) check(fset *token.FileSet, file *ast.File) (*types.Info, error) {
+	// Clear the type-checker errors and create a struct to hold type info
+	i.checker.errs = i.checker.errs[:0]
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	files := []*ast.File{file}
+	pkg, _ := i.checker.config.Check("", fset, files, info)
+	if len(i.checker.errs) > 0 {
+		return nil, i.checker.errs[0]
+	}
+	i.pkg = pkg
+	return info, nil
+}
+
+func (i *interp) runTopLevel(stmtList []ast.Stmt) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if e, ok := p.(unsupportedError); ok {
+				err = e
+				return
+			}
+			err = fmt.Errorf("panic: %v", unboxed(reflect.ValueOf(&p).Elem()).Interface())
+		}
+	}()
+	i.topEnv.frame = newFrame(nil)
+	i.topEnv.frame.run(func() {
+		stmtRes := i.topEnv.runStmtList(stmtList, true)
+		if _, ok := stmtRes.(gotoResult); ok {
+			err = fmt.Errorf("goto %s: label is in an earlier input", stmtRes)
+		} else if stmtRes != nil {
+			log.Fatal("return from top level not allowed")
+		}
+	})
+	return err
+}
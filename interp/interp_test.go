@@ -0,0 +1,218 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "golang.org/x/tools/go/gcimporter"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// testObjs are the objects of imported packages that the inputs of the tests
+// can use, given to the interpreter as the program generated by main.go would
+// give them.
+var testObjs = map[string]map[string]interface{}{
+	"bytes": {
+		"NewBufferString": bytes.NewBufferString,
+	},
+	"encoding/json": {
+		"Marshal": json.Marshal,
+	},
+	"errors": {
+		"New": errors.New,
+	},
+	"fmt": {
+		"Errorf":  fmt.Errorf,
+		"Print":   fmt.Print,
+		"Printf":  fmt.Printf,
+		"Println": fmt.Println,
+		"Sprint":  fmt.Sprint,
+		"Sprintf": fmt.Sprintf,
+	},
+	"image": {
+		"Pt": image.Pt,
+	},
+	"sort": {
+		"Slice": sort.Slice,
+		"Sort":  sort.Sort,
+	},
+	"strings": {
+		"FieldsFunc": strings.FieldsFunc,
+		"Join":       strings.Join,
+		"Map":        strings.Map,
+		"Split":      strings.Split,
+		"ToUpper":    strings.ToUpper,
+	},
+}
+
+// testTypes are the types of imported packages that the inputs of the tests
+// can use, each given as a nil pointer to the type.
+var testTypes = map[string]map[string]interface{}{
+	"bytes": {
+		"Buffer": (*bytes.Buffer)(nil),
+	},
+	"fmt": {
+		"Stringer": (*fmt.Stringer)(nil),
+	},
+	"image": {
+		"Point": (*image.Point)(nil),
+	},
+	"sort": {
+		"Interface": (*sort.Interface)(nil),
+	},
+}
+
+// testProxies make the proxies that the generated program would declare for
+// the interface types of testTypes, and for error, which it finds among the
+// types that the objects of its packages use.
+var testProxies = map[string]map[string]ProxyMaker{
+	"errors": {
+		"error": func(p *Proxy) interface{} { return errorProxy{p} },
+	},
+	"fmt": {
+		"Stringer": func(p *Proxy) interface{} { return stringerProxy{p} },
+	},
+	"sort": {
+		"Interface": func(p *Proxy) interface{} { return sortProxy{p} },
+	},
+}
+
+type errorProxy struct {
+	*Proxy
+}
+
+func (p errorProxy) Error() string {
+	return p.Proxy.Call("Error", nil)[0].String()
+}
+
+type stringerProxy struct {
+	*Proxy
+}
+
+func (p stringerProxy) String() string {
+	return p.Proxy.Call("String", nil)[0].String()
+}
+
+type sortProxy struct {
+	*Proxy
+}
+
+func (p sortProxy) Len() int {
+	return int(p.Proxy.Call("Len", nil)[0].Int())
+}
+
+func (p sortProxy) Less(i, j int) bool {
+	return p.Proxy.Call("Less", []reflect.Value{reflect.ValueOf(i), reflect.ValueOf(j)})[0].Bool()
+}
+
+func (p sortProxy) Swap(i, j int) {
+	p.Proxy.Call("Swap", []reflect.Value{reflect.ValueOf(i), reflect.ValueOf(j)})
+}
+
+// newTestInterp returns a new interpreter for the packages of testObjs.
+func newTestInterp(t *testing.T) Interpreter {
+	pkgMap := map[string]*types.Package{}
+	typeMap := new(typeutil.Map)
+	pkgs := []*Package{}
+	// The generated program registers error as one of the types that the
+	// objects of its packages use
+	typeMap.Set(types.Universe.Lookup("error").Type(), reflect.TypeOf((*error)(nil)).Elem())
+	for path, objs := range testObjs {
+		tpkg, err := types.DefaultImport(pkgMap, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg := &Package{
+			Name:    tpkg.Name(),
+			Pkg:     tpkg,
+			Objs:    map[string]Object{},
+			Proxies: map[types.Type]ProxyMaker{},
+		}
+		pkgs = append(pkgs, pkg)
+		for name, x := range objs {
+			typ := tpkg.Scope().Lookup(name).Type()
+			val := reflect.ValueOf(x)
+			typeMap.Set(typ, val.Type())
+			pkg.Objs[name] = Object{
+				Value: val,
+				Typ:   typ,
+			}
+		}
+		for name, x := range testTypes[path] {
+			typ := tpkg.Scope().Lookup(name).Type()
+			rtyp := reflect.TypeOf(x).Elem()
+			typeMap.Set(typ, rtyp)
+			typeMap.Set(types.NewPointer(typ), reflect.PtrTo(rtyp))
+		}
+		for name, makeProxy := range testProxies[path] {
+			obj := tpkg.Scope().Lookup(name)
+			if obj == nil {
+				obj = types.Universe.Lookup(name)
+			}
+			pkg.Proxies[obj.Type()] = makeProxy
+		}
+	}
+	return NewInterpreter(pkgs, pkgMap, typeMap)
+}
+
+// A runTest gives each of its inputs in turn to a new interpreter, and says
+// what they should print. An error returned by Run is printed as part of the
+// output.
+type runTest struct {
+	name   string
+	inputs []string
+	want   string
+}
+
+func runTests(t *testing.T, tests []runTest) {
+	for _, test := range tests {
+		if got := runInputs(t, test.inputs); got != test.want {
+			t.Errorf("%s: got output\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+// runInputs gives the inputs to a new interpreter, and returns what they print
+// to standard output. The lines that show the results of top-level expression
+// statements, such as fmt.Println(x), are left out.
+func runInputs(t *testing.T, inputs []string) string {
+	i := newTestInterp(t)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+	}()
+	out := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+	for _, src := range inputs {
+		if _, err := i.Run(src); err != nil {
+			fmt.Fprintln(w, "error:", err)
+		}
+	}
+	w.Close()
+	var lines []string
+	for _, line := range strings.SplitAfter(<-out, "\n") {
+		if !strings.HasPrefix(line, "=> ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,146 @@
+package interp
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+
+	"golang.org/x/tools/go/types"
+)
+
+// methodValue evaluates the method value x.M denoted by e. The receiver is
+// evaluated now, following the path to a promoted method, if any.
+func (env *environ) methodValue(e *ast.SelectorExpr, sel *types.Selection) Object {
+	xObj := env.Eval(e.X)[0]
+	recvVal := env.interp.methodRecv(xObj.Value.(reflect.Value), sel)
+	obj := env.interp.bindMethod(recvVal, sel.Obj().(*types.Func))
+	obj.Typ = sel.Type()
+	return obj
+}
+
+// methodExpr evaluates the method expression T.M denoted by sel. The result is
+// a function that takes the receiver as its first argument, and calls the
+// method value of that receiver with the rest of the arguments.
+func (env *environ) methodExpr(sel *types.Selection) Object {
+	method := sel.Obj().(*types.Func)
+	f := func(in []Object) []Object {
+		recvVal := env.interp.methodRecv(in[0].Value.(reflect.Value), sel)
+		return callFunObj(env.interp.bindMethod(recvVal, method), in[1:])
+	}
+
+	sig := sel.Type().(*types.Signature)
+	rtyp, sim := getReflectType(env.interp.typeMap, sig)
+	if sim {
+		return Object{
+			Value: reflect.ValueOf(f),
+			Typ:   sig,
+			Sim:   true,
+		}
+	}
+	return Object{
+		Value: wrapSimulatedFunc(f, sig, rtyp),
+		Typ:   sig,
+	}
+}
+
+// methodRecv returns the receiver of the method selected by sel from recvVal, a
+// value of type sel.Recv(). For a promoted method, that's the embedded field
+// that has the method, found through any embedded pointers. A field that
+// refers back to its struct type is given its real type.
+func (i *interp) methodRecv(recvVal reflect.Value, sel *types.Selection) reflect.Value {
+	index := sel.Index()
+	if len(index) == 1 {
+		return recvVal
+	}
+	field := fieldByIndex(recvVal, index[:len(index)-1])
+	if field.Type() != recursiveFieldType {
+		return field
+	}
+	typ := sel.Recv()
+	for _, fieldIndex := range index[:len(index)-1] {
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		typ = typ.Underlying().(*types.Struct).Field(fieldIndex).Type()
+	}
+	rtyp, _ := getReflectType(i.typeMap, typ)
+	return fieldValue(field, rtyp)
+}
+
+// bindMethod returns the method value of method for the receiver recvVal. The
+// receiver's address is taken, or the receiver is dereferenced, as the method
+// requires. A value receiver is copied, so that later changes to the variable
+// it came from don't affect the method value.
+//
+// The reflect package can't attach methods to the types it creates, so methods
+// of named types declared in the session are called by the interpreter itself.
+// Methods of interface types declared in the session are found by ifaceMethod.
+// Other methods are found with reflect.Value.MethodByName.
+func (i *interp) bindMethod(recvVal reflect.Value, method *types.Func) Object {
+	if recvVal.Type() == sessionIfaceType {
+		if recvVal.IsNil() {
+			panic(errNilDeref)
+		}
+		return i.ifaceMethod(recvVal, method)
+	}
+
+	sig := method.Type().(*types.Signature)
+	recvTyp := sig.Recv().Type()
+	ptrRecv := false
+	if ptr, ok := recvTyp.(*types.Pointer); ok {
+		ptrRecv = true
+		recvTyp = ptr.Elem()
+	}
+	_, isIface := recvTyp.Underlying().(*types.Interface)
+
+	switch {
+	case isIface:
+		if recvVal.IsNil() {
+			panic(errNilDeref)
+		}
+	case ptrRecv && recvVal.Kind() != reflect.Ptr:
+		recvVal = recvVal.Addr()
+	case !ptrRecv && recvVal.Kind() == reflect.Ptr:
+		if recvVal.IsNil() {
+			panic(errNilDeref)
+		}
+		recvVal = recvVal.Elem()
+	}
+	recvObj := copyObjs([]Object{{Value: recvVal, Typ: sig.Recv().Type()}})[0]
+
+	rtyp, sim := getReflectType(i.typeMap, sig)
+	var bound func([]Object) []Object
+	if named, ok := recvTyp.(*types.Named); ok && isSessionType(named) && !isIface {
+		key := named.Obj().Name() + "." + method.Name()
+		m, ok := i.methods[key]
+		if !ok {
+			panic(unsupportedError(fmt.Sprintf("method %s is not declared", key)))
+		}
+		bound = func(in []Object) []Object {
+			return m(append([]Object{recvObj}, in...))
+		}
+	} else {
+		methodVal := recvObj.Value.(reflect.Value).MethodByName(method.Name())
+		if !sim {
+			return Object{
+				Value: methodVal,
+				Typ:   sig,
+			}
+		}
+		bound = func(in []Object) []Object {
+			return callFunObj(Object{Value: methodVal, Typ: sig}, in)
+		}
+	}
+
+	if sim {
+		return Object{
+			Value: reflect.ValueOf(bound),
+			Typ:   sig,
+			Sim:   true,
+		}
+	}
+	return Object{
+		Value: wrapSimulatedFunc(bound, sig, rtyp),
+		Typ:   sig,
+	}
+}
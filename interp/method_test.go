@@ -0,0 +1,71 @@
+package interp
+
+import "testing"
+
+func TestMethodValue(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "imported type",
+			inputs: []string{
+				`buf := bytes.NewBufferString("abc")`,
+				"write := buf.WriteString\nstr := buf.String",
+				`write("def")`,
+				"fmt.Println(str())",
+			},
+			want: "abcdef\n",
+		},
+		{
+			name: "receiver copied when bound",
+			inputs: []string{
+				"type N int\nfunc (n N) Get() N { return n }\nfunc (n *N) Set(v N) { *n = v }",
+				"var n N = 1",
+				"get := n.Get\nset := n.Set",
+				"set(5)",
+				"fmt.Println(get(), n.Get(), n)",
+			},
+			want: "1 5 5\n",
+		},
+		{
+			name: "nil pointer receiver",
+			inputs: []string{
+				"type N int\nfunc (n N) Get() N { return n }",
+				"var p *N",
+				"fmt.Println(p.Get())",
+			},
+			want: "error: panic: runtime error: invalid memory address or nil pointer dereference\n",
+		},
+		{
+			name: "promoted method",
+			inputs: []string{
+				"type Inner int\nfunc (i Inner) Get() int { return int(i) }",
+				"type Outer struct{ Inner }",
+				"get := Outer{3}.Get",
+				"fmt.Println(get(), Outer.Get(Outer{4}))",
+			},
+			want: "3 4\n",
+		},
+	})
+}
+
+func TestMethodExpr(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "imported type",
+			inputs: []string{
+				"str := (*bytes.Buffer).String",
+				`fmt.Println(str(bytes.NewBufferString("xyz")))`,
+			},
+			want: "xyz\n",
+		},
+		{
+			name: "session type",
+			inputs: []string{
+				"type N int\nfunc (n N) Add(m N) N { return n + m }\nfunc (n *N) Inc() { *n = *n + 1 }",
+				"add := N.Add\ninc := (*N).Inc",
+				"var n N = 2\ninc(&n)",
+				"fmt.Println(add(n, 4))",
+			},
+			want: "7\n",
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package interp
 
 import (
+	"go/ast"
 	"go/token"
 	"log"
 	"reflect"
@@ -72,6 +73,9 @@ func doBinaryComparisonOp(env *environ, left, right Object, op token.Token, typ
 		obj = operatorGreaterEqual(env, left, right, typ)
 	case token.EQL:
 		obj = operatorEqual(env, left, right, typ)
+	case token.NEQ:
+		equal := operatorEqual(env, left, right, typ)
+		obj = newBoolObject(env, !getBool(equal), typ)
 	default:
 		// TODO: Implement other binary operators
 		log.Fatalf("Binary comparison operator %v not implemented yet", op)
@@ -79,11 +83,29 @@ func doBinaryComparisonOp(env *environ, left, right Object, op token.Token, typ
 	return obj
 }
 
+// getInt returns the value of an integer operand, such as an index, as an int.
+func getInt(obj Object) int {
+	val := getTypedObject(obj).Value.(reflect.Value)
+	switch val.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(val.Uint())
+	}
+	return int(val.Int())
+}
+
 func getTypedObject(obj Object) Object {
 	if isTyped(obj.Typ) {
 		return obj
 	}
 	t := obj.Typ.Underlying().(*types.Basic)
+	if val, ok := obj.Value.(reflect.Value); ok {
+		// An untyped boolean that isn't constant, such as the result of a
+		// comparison, already has a value of type bool
+		return Object{
+			Value: val,
+			Typ:   types.Typ[types.Bool],
+		}
+	}
 	ev := obj.Value.(exact.Value)
 	switch t.Kind() {
 	case types.UntypedBool:
@@ -1037,27 +1059,112 @@ func operatorEqual(env *environ, left, right Object, typ types.Type) Object {
 	case rightIsUntypedNil:
 		equal = lv.IsNil()
 	case types.Identical(left.Typ, right.Typ):
-		equal = lv.Interface() == rv.Interface()
+		equal = env.interp.valuesEqual(lv, rv)
 	case types.AssignableTo(left.Typ, right.Typ):
-		clv := lv.Convert(rv.Type())
-		equal = clv.Interface() == rv.Interface()
+		clv := env.interp.proxyFor(left, rv.Type()).Value.(reflect.Value).Convert(rv.Type())
+		equal = env.interp.valuesEqual(clv, rv)
 	default:
-		crv := rv.Convert(lv.Type())
-		equal = lv.Interface() == crv.Interface()
+		crv := env.interp.proxyFor(right, lv.Type()).Value.(reflect.Value).Convert(lv.Type())
+		equal = env.interp.valuesEqual(lv, crv)
 	}
 
+	return newBoolObject(env, equal, typ)
+}
+
+// newBoolObject returns an Object of boolean type typ with the value b.
+func newBoolObject(env *environ, b bool, typ types.Type) Object {
 	var newVal reflect.Value
 	if _, isNamed := typ.(*types.Named); isNamed {
 		// Type is not "bool" but some other named boolean type.
 		newRtyp, _ := getReflectType(env.interp.typeMap, typ)
 		if newRtyp == nil {
-			log.Fatal("operatorEqual: Couldn't get reflect.Type from types.Type")
+			log.Fatal("newBoolObject: Couldn't get reflect.Type from types.Type")
 		}
 		newVal = reflect.New(newRtyp).Elem()
-		newVal.SetBool(equal)
+		newVal.SetBool(b)
 	} else {
 		// Type is "bool" or "untyped bool". Use "bool".
-		newVal = reflect.ValueOf(equal)
+		newVal = reflect.ValueOf(b)
+	}
+
+	return Object{
+		Value: newVal,
+		Typ:   typ,
+	}
+}
+
+// getBool returns the value of a boolean operand, which may be an untyped
+// constant.
+func getBool(obj Object) bool {
+	if ev, ok := obj.Value.(exact.Value); ok {
+		return exact.BoolVal(ev)
+	}
+	return obj.Value.(reflect.Value).Bool()
+}
+
+// operatorLogical implements the binary operations '&&' and '||'. The right
+// operand is only evaluated if the left one doesn't determine the result.
+func operatorLogical(env *environ, e *ast.BinaryExpr, typ types.Type) Object {
+	left := getBool(env.Eval(e.X)[0])
+	if (e.Op == token.LAND && !left) || (e.Op == token.LOR && left) {
+		return newBoolObject(env, left, typ)
+	}
+	right := getBool(env.Eval(e.Y)[0])
+	return newBoolObject(env, right, typ)
+}
+
+// operatorNegate implements the unary operation '-'. For integers, the result
+// wraps around, so the negation of the most negative value is itself.
+func operatorNegate(env *environ, x Object, typ types.Type) Object {
+	x = getTypedObject(x)
+	xv := x.Value.(reflect.Value)
+
+	newRtyp, _ := getReflectType(env.interp.typeMap, typ)
+	if newRtyp == nil {
+		log.Fatal("operatorNegate: Couldn't get reflect.Type from types.Type")
+	}
+	newVal := getSettableZeroVal(newRtyp)
+
+	// The Set methods truncate to the size of the value, which wraps around
+	switch xv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		newVal.SetInt(-xv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		newVal.SetUint(-xv.Uint())
+	case reflect.Float32, reflect.Float64:
+		newVal.SetFloat(-xv.Float())
+	case reflect.Complex64, reflect.Complex128:
+		newVal.SetComplex(-xv.Complex())
+	default:
+		panic("Type error: Invalid operand to negation: " + TypeString(x.Typ))
+	}
+
+	return Object{
+		Value: newVal,
+		Typ:   typ,
+	}
+}
+
+// operatorComplement implements the unary operation '^'.
+func operatorComplement(env *environ, x Object, typ types.Type) Object {
+	x = getTypedObject(x)
+	xv := x.Value.(reflect.Value)
+
+	newRtyp, _ := getReflectType(env.interp.typeMap, typ)
+	if newRtyp == nil {
+		log.Fatal("operatorComplement: Couldn't get reflect.Type from types.Type")
+	}
+	newVal := getSettableZeroVal(newRtyp)
+
+	// The Set methods truncate to the size of the value, so the complement of
+	// an unsigned value only has as many bits as the value does
+	switch xv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		newVal.SetInt(^xv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		newVal.SetUint(^xv.Uint())
+	default:
+		panic("Type error: Invalid operand to bitwise complement: " + TypeString(x.Typ))
 	}
 
 	return Object{
@@ -0,0 +1,56 @@
+package interp
+
+import "testing"
+
+func TestLogicalOp(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "short circuit",
+			inputs: []string{
+				"f := func(s string, b bool) bool { fmt.Print(s, \" \"); return b }",
+				`fmt.Println(f("a", false) && f("b", true))`,
+				`fmt.Println(f("c", true) || f("d", true))`,
+				`fmt.Println(f("e", true) && f("f", false) || f("g", true))`,
+			},
+			want: "a false\nc true\ne f g true\n",
+		},
+		{
+			name: "not and not equal",
+			inputs: []string{
+				"var p *int\nok := p == nil",
+				"x, y := 1, 2",
+				"fmt.Println(!ok, p != nil, x != y, !(x != 1))",
+			},
+			want: "false false true true\n",
+		},
+	})
+}
+
+func TestUnaryOp(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "negation",
+			inputs: []string{
+				"i := 5\nf := 2.5\nc := complex(1, -2)",
+				"fmt.Println(-i, +i, -f, -c, -(-3))",
+			},
+			want: "-5 5 -2.5 (-1+2i) 3\n",
+		},
+		{
+			name: "overflow wraps around",
+			inputs: []string{
+				"var a int8 = -128\nvar b int64 = -9223372036854775808",
+				"fmt.Println(-a, -b)",
+			},
+			want: "-128 -9223372036854775808\n",
+		},
+		{
+			name: "complement",
+			inputs: []string{
+				"var u uint8 = 5\nx := 6",
+				"fmt.Println(^u, ^x, ^0)",
+			},
+			want: "250 -7 -1\n",
+		},
+	})
+}
@@ -0,0 +1,408 @@
+package interp
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"log"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A pkgDecl is the source of a function, method, or type declared at the top
+// level of the session. These are lifted out of func _ to package level in the
+// file that gets type checked, so they may be recursive, may refer to each
+// other across inputs, and types may have methods.
+type pkgDecl struct {
+	key string // The declared name, or "T.M" for method M of type T
+	src string
+}
+
+// isPkgDecl reports whether src begins with a function, method, or type
+// declaration rather than a statement (such as a call of a function literal).
+func isPkgDecl(src string) bool {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+	_, tok, _ := s.Scan()
+	switch tok {
+	case token.TYPE:
+		return true
+	case token.FUNC:
+	default:
+		return false
+	}
+	_, tok, _ = s.Scan()
+	if tok == token.IDENT {
+		return true
+	}
+	if tok != token.LPAREN {
+		return false
+	}
+	// Skip what's either a receiver or the parameters of a function literal. A
+	// method name followed by its parameters comes after a receiver, while the
+	// parameters of a function literal can only be followed by its result type.
+	for depth := 1; depth > 0; {
+		switch _, tok, _ = s.Scan(); tok {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+		case token.EOF:
+			return false
+		}
+	}
+	if _, tok, _ = s.Scan(); tok != token.IDENT {
+		return false
+	}
+	_, tok, _ = s.Scan()
+	return tok == token.LPAREN
+}
+
+// runPkgDecls handles an input made up of package-level declarations. Each
+// declaration replaces any earlier declaration of the same name. Declarations
+// that refer to names that aren't declared yet are kept pending until a later
+// input declares them, so that mutually recursive functions can be entered one
+// at a time. The error returned for them is a *PendingError.
+func (i *interp) runPkgDecls(src string) (bool, error) {
+	// Parse the declarations on their own, to split them up
+	declSrc := "package p;" + src
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "input", declSrc, 0)
+	if err != nil {
+		if errList, ok := err.(scanner.ErrorList); ok {
+			if errList[0].Pos.Offset >= len(declSrc)-1 && errList[0].Msg != "expected declaration, found '}'" {
+				// The source is just incomplete
+				i.oldSrc = src
+				return true, nil
+			}
+		} else {
+			log.Fatal("Parsing yielded a non-nil error that's not a scanner.ErrorList")
+		}
+		return false, err
+	}
+
+	nodeSrc := func(node ast.Node) string {
+		start := fset.Position(node.Pos()).Offset
+		end := fset.Position(node.End()).Offset
+		return declSrc[start:end]
+	}
+	var newDecls []pkgDecl
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if name == "_" || name == "init" {
+				err := fmt.Errorf("cannot declare %s at the top level", name)
+				return false, err
+			}
+			if decl.Body == nil {
+				err := fmt.Errorf("missing function body for %s", name)
+				return false, err
+			}
+			key := name
+			if decl.Recv != nil {
+				key = recvTypeName(decl.Recv.List[0].Type) + "." + name
+			}
+			newDecls = replacePkgDecl(newDecls, pkgDecl{key: key, src: nodeSrc(decl)})
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				err := fmt.Errorf("Only function and type declarations may be entered along with a function or type declaration")
+				return false, err
+			}
+			// Keep each type separately, so it can be replaced on its own
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if spec.Name.Name == "_" {
+					continue
+				}
+				newDecls = replacePkgDecl(newDecls, pkgDecl{key: spec.Name.Name, src: "type " + nodeSrc(spec)})
+			}
+		default:
+			log.Fatalf("Unexpected declaration: %T", decl)
+		}
+	}
+
+	// Check the new declarations along with the ones already defined
+	decls := append([]pkgDecl{}, i.pkgDecls...)
+	for _, decl := range newDecls {
+		decls = replacePkgDecl(decls, decl)
+	}
+	file, info, err := i.checkPkgDecls(decls)
+	if err == nil {
+		i.buildDeclaredTypes(file, info)
+		i.pkgDecls = decls
+		i.definePkgDecls(file, info)
+		for key, err := range i.retryPendingDecls() {
+			return false, fmt.Errorf("%s can't be declared: %v", key, err)
+		}
+		return false, nil
+	}
+	names, ok := i.undeclaredNames()
+	if !ok {
+		return false, err
+	}
+	for _, name := range names {
+		if i.isInputName(name) {
+			err := fmt.Errorf("%s is declared in an input, so it can't be used at the top level", name)
+			return false, err
+		}
+	}
+
+	// Wait for the missing names to be declared. The pending declarations may
+	// be waiting for the new ones, so try them all again together.
+	for _, decl := range newDecls {
+		i.pendingDecls = replacePkgDecl(i.pendingDecls, decl)
+	}
+	dropped := i.retryPendingDecls()
+	pendingErr := &PendingError{Names: names}
+	for _, decl := range newDecls {
+		if err, ok := dropped[decl.key]; ok {
+			return false, err
+		}
+		if i.isPending(decl.key) {
+			pendingErr.Decls = append(pendingErr.Decls, decl.key)
+		}
+	}
+	if len(pendingErr.Decls) > 0 {
+		return false, pendingErr
+	}
+	return false, nil
+}
+
+// checkPkgDecls type checks decls, the package-level declarations, along with
+// the statements of the earlier inputs.
+func (i *interp) checkPkgDecls(decls []pkgDecl) (*ast.File, *types.Info, error) {
+	allSrc := i.buildSrc(decls, "")
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "input", allSrc, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := i.check(fset, file)
+	return file, info, err
+}
+
+// definePkgDecls (re)defines all the functions and methods declared in file,
+// which has just been checked, giving info. Even the ones that haven't changed
+// refer to the type info of the file they were checked in. Types only matter
+// to the type checker, so there's nothing to do for them.
+func (i *interp) definePkgDecls(file *ast.File, info *types.Info) {
+	declEnv := &environ{
+		info:   info,
+		interp: i,
+		scope:  info.Scopes[file],
+		parent: i.pkgEnv,
+		objs:   map[string]Object{},
+	}
+	i.methods = map[string]func([]Object) []Object{}
+	for _, decl := range file.Decls {
+		fdecl, ok := decl.(*ast.FuncDecl)
+		if !ok || fdecl.Name.Name == "_" {
+			continue
+		}
+		if fdecl.Recv != nil {
+			// Methods are always simulated, with the receiver as the first argument
+			sig := info.Defs[fdecl.Name].Type().(*types.Signature)
+			key := recvTypeName(fdecl.Recv.List[0].Type) + "." + fdecl.Name.Name
+			i.methods[key] = createSimulatedFunc(declEnv, sig, fdecl.Type, fdecl.Body, nil)
+			continue
+		}
+		i.pkgEnv.objs[fdecl.Name.Name] = declEnv.createFuncObj(fdecl, nil)
+		i.deferredFuncs[fdecl.Name.Name] = func(deferrer *frame) Object {
+			return declEnv.createFuncObj(fdecl, deferrer)
+		}
+	}
+}
+
+// buildDeclaredTypes builds the reflect types of the types declared in file,
+// which has just been checked, giving info. A type that the reflect package
+// can't represent is refused with an unsupportedError panic before it's
+// declared.
+func (i *interp) buildDeclaredTypes(file *ast.File, info *types.Info) {
+	for _, decl := range file.Decls {
+		gdecl, ok := decl.(*ast.GenDecl)
+		if !ok || gdecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gdecl.Specs {
+			getReflectType(i.typeMap, info.Defs[spec.(*ast.TypeSpec).Name].Type())
+		}
+	}
+}
+
+// retryPendingDecls defines the pending declarations that are no longer
+// waiting for undeclared names. The pending declarations are checked together
+// with the defined ones, leaving out those that the errors are in, until they
+// check without errors. A declaration with errors other than undeclared names
+// can't ever be defined, so it's dropped, and its first error is returned
+// under its key.
+func (i *interp) retryPendingDecls() map[string]error {
+	dropped := map[string]error{}
+	var waiting []pkgDecl
+	retry := i.pendingDecls
+	for len(retry) > 0 {
+		decls := append([]pkgDecl{}, i.pkgDecls...)
+		for _, decl := range retry {
+			decls = replacePkgDecl(decls, decl)
+		}
+		file, info, err := i.checkPkgDecls(decls)
+		if err == nil {
+			i.buildDeclaredTypes(file, info)
+			i.pkgDecls = decls
+			i.definePkgDecls(file, info)
+			break
+		}
+		if file == nil {
+			// A declaration that parsed on its own always parses with the others
+			waiting = append(waiting, retry...)
+			break
+		}
+		errs := i.errsByDecl(file, decls)
+		var next []pkgDecl
+		for _, decl := range retry {
+			switch {
+			case len(errs[decl.key]) == 0:
+				next = append(next, decl)
+			case allUndeclared(errs[decl.key]):
+				waiting = append(waiting, decl)
+			default:
+				dropped[decl.key] = errs[decl.key][0]
+			}
+		}
+		if len(next) == len(retry) {
+			// None of the errors are in the pending declarations, so they may
+			// only be checked once whatever they conflict with is replaced
+			waiting = append(waiting, retry...)
+			break
+		}
+		retry = next
+	}
+	i.pendingDecls = waiting
+	return dropped
+}
+
+// errsByDecl returns the errors from the last type check of file, which was
+// built from decls, by the key of the declaration each is in.
+func (i *interp) errsByDecl(file *ast.File, decls []pkgDecl) map[string][]error {
+	errs := map[string][]error{}
+	for _, err := range i.checker.errs {
+		e, ok := err.(types.Error)
+		if !ok {
+			continue
+		}
+		// The declarations come after the imports, in the order of decls
+		for j, decl := range file.Decls[1 : len(decls)+1] {
+			if decl.Pos() <= e.Pos && e.Pos < decl.End() {
+				errs[decls[j].key] = append(errs[decls[j].key], err)
+			}
+		}
+	}
+	return errs
+}
+
+// isPending reports whether the declaration with the given key is pending.
+func (i *interp) isPending(key string) bool {
+	for _, decl := range i.pendingDecls {
+		if decl.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isInputName reports whether name is declared by the statements of an
+// earlier input, which are local to the function that holds them.
+func (i *interp) isInputName(name string) bool {
+	if i.topEnv.scope == nil {
+		return false
+	}
+	scope, obj := i.topEnv.scope.LookupParent(name)
+	if _, isPkgName := obj.(*types.PkgName); obj == nil || isPkgName {
+		return false
+	}
+	return scope != types.Universe && scope.Parent() != types.Universe
+}
+
+// recvTypeName returns the name of the type in the receiver type expression of a
+// method declaration, which is either T or *T (possibly parenthesized).
+func recvTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return recvTypeName(e.X)
+	case *ast.ParenExpr:
+		return recvTypeName(e.X)
+	}
+	return ""
+}
+
+// undeclaredNames returns the names that aren't declared, if that's what all the
+// errors from the last type check are about.
+func (i *interp) undeclaredNames() ([]string, bool) {
+	if !allUndeclared(i.checker.errs) {
+		return nil, false
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, err := range i.checker.errs {
+		e := err.(types.Error)
+		name := strings.TrimPrefix(e.Msg, "undeclared name: ")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, true
+}
+
+// allUndeclared reports whether all of errs are type errors about names that
+// aren't declared.
+func allUndeclared(errs []error) bool {
+	for _, err := range errs {
+		e, ok := err.(types.Error)
+		if !ok || !strings.HasPrefix(e.Msg, "undeclared name: ") {
+			return false
+		}
+	}
+	return true
+}
+
+// replacePkgDecl adds decl to decls, removing any declaration with the same key.
+func replacePkgDecl(decls []pkgDecl, decl pkgDecl) []pkgDecl {
+	newDecls := []pkgDecl{}
+	for _, d := range decls {
+		if d.key != decl.key {
+			newDecls = append(newDecls, d)
+		}
+	}
+	return append(newDecls, decl)
+}
+
+// createFuncObj creates the function declared by fdecl, with env as the parent
+// environment of its calls. Other package-level functions are not closed over,
+// but looked up when they're used, so redefining one affects later callers.
+// If the function is made for a single deferred call, deferrer is the frame
+// that defers it, and otherwise nil.
+func (env *environ) createFuncObj(fdecl *ast.FuncDecl, deferrer *frame) Object {
+	sig := env.info.Defs[fdecl.Name].Type().(*types.Signature)
+	rtyp, sim := getReflectType(env.interp.typeMap, sig)
+	if sim {
+		f := createSimulatedFunc(env, sig, fdecl.Type, fdecl.Body, deferrer)
+		return Object{
+			Value: reflect.ValueOf(f),
+			Typ:   sig,
+			Sim:   true,
+		}
+	}
+	return Object{
+		Value: createUnsimulatedFunc(env, sig, fdecl.Type, fdecl.Body, rtyp, deferrer),
+		Typ:   sig,
+	}
+}
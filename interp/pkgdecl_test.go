@@ -0,0 +1,116 @@
+package interp
+
+import "testing"
+
+func TestFuncDecl(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "recursion over several lines",
+			inputs: []string{
+				"func fib(n int) int {",
+				"\tif n < 2 {\n\t\treturn n\n\t}",
+				"\treturn fib(n-1) + fib(n-2)\n}",
+				"fmt.Println(fib(20))",
+			},
+			want: "6765\n",
+		},
+		{
+			name: "mutual recursion",
+			inputs: []string{
+				"func isEven(n int) bool { if n == 0 { return true }; return isOdd(n-1) }",
+				"func isOdd(n int) bool { if n == 0 { return false }; return isEven(n-1) }",
+				"fmt.Println(isEven(10), isOdd(7))",
+			},
+			want: "error: isEven pending: waiting for isOdd to be declared\ntrue true\n",
+		},
+		{
+			name: "redeclaration",
+			inputs: []string{
+				"func fib(n int) int { if n < 2 { return n }; return fib(n-1) + fib(n-2) }",
+				"g := func() int { return fib(10) }",
+				"fmt.Println(g())",
+				"func fib(n int) int { return 0 - n }",
+				"fmt.Println(g(), fib(3))",
+			},
+			want: "55\n-10 -3\n",
+		},
+		{
+			name: "several in one input",
+			inputs: []string{
+				"x := 5",
+				"func add(a, b int) int { return a + b }\nfunc sub(a, b int) int { return a - b }",
+				"func apply(f func(int) int, v int) int { return f(v) }",
+				"fmt.Println(add(x, 2), sub(x, 2), apply(func(i int) int { return i * 3 }, 4))",
+				`func() { fmt.Println("lit") }()`,
+			},
+			want: "7 3 12\nlit\n",
+		},
+		{
+			name: "pending",
+			inputs: []string{
+				"func bad() int { return undeclared }",
+				"fmt.Println(1)",
+			},
+			want: "error: bad pending: waiting for undeclared to be declared\n1\n",
+		},
+		{
+			name: "pending declarations don't hold up new ones",
+			inputs: []string{
+				"func bad() int { return later() }",
+				"func good() int { return 2 }",
+				"fmt.Println(good())",
+				"func later() int { return 3 }",
+				"fmt.Println(bad())",
+			},
+			want: "error: bad pending: waiting for later to be declared\n2\n3\n",
+		},
+		{
+			name: "input-level variable",
+			inputs: []string{
+				"y := 4",
+				"func useY() int { return y }",
+				"fmt.Println(y)",
+			},
+			want: "error: y is declared in an input, so it can't be used at the top level\n4\n",
+		},
+	})
+}
+
+func TestMethodDecl(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "value receiver",
+			inputs: []string{
+				"type Celsius float64",
+				"func (c Celsius) F() float64 { return float64(c)*9/5 + 32 }",
+				"c := Celsius(100)",
+				"fmt.Println(c.F())",
+			},
+			want: "212\n",
+		},
+		{
+			name: "pointer receiver and method values",
+			inputs: []string{
+				"type Counter int\nfunc (c *Counter) Inc() { *c = *c + 1 }\nfunc (c Counter) Get() int { return int(c) }",
+				"var n Counter",
+				"n.Inc()\nn.Inc()",
+				"fmt.Println(n.Get())",
+				"p := &n\np.Inc()",
+				"get := n.Get\ninc := p.Inc\ninc()",
+				"fmt.Println(get(), n.Get(), p.Get())",
+				"func (c Counter) Twice() int { return c.Get() * 2 }",
+				"fmt.Println(n.Twice())",
+			},
+			want: "2\n3 4 4\n8\n",
+		},
+		{
+			name: "method declared before its type",
+			inputs: []string{
+				`func (n Name) String() string { return "name:" + string(n) }`,
+				"type Name string",
+				`fmt.Println(Name("bob").String())`,
+			},
+			want: "error: Name.String pending: waiting for Name to be declared\nname:bob\n",
+		},
+	})
+}
@@ -0,0 +1,350 @@
+package interp
+
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Proxy stands in for a value of a type declared in the session, as the
+// dynamic value of an interface type from an imported package. The reflect
+// package can't make types with methods, so the program generated by main.go
+// declares a proxy type for each such interface type. It embeds *Proxy, and its
+// methods forward to Call. That way, native code such as sort.Sort can call the
+// methods of values declared in the session.
+type Proxy struct {
+	obj    Object
+	interp *interp
+}
+
+// A ProxyMaker makes a value of a proxy type that forwards to p.
+type ProxyMaker func(p *Proxy) interface{}
+
+// Call calls the method of the given name on the value behind the proxy.
+func (p *Proxy) Call(name string, args []reflect.Value) []reflect.Value {
+	obj := p.object()
+	sel := lookupMethod(obj.Typ, name)
+	if sel == nil {
+		panic(unsupportedError(fmt.Sprintf("method %s of %v not found", name, obj.Typ)))
+	}
+	recvVal := p.interp.methodRecv(obj.Value.(reflect.Value), sel)
+	method := sel.Obj().(*types.Func)
+	funObj := p.interp.bindMethod(recvVal, method)
+
+	params := method.Type().(*types.Signature).Params()
+	argObjs := make([]Object, len(args))
+	for i, arg := range args {
+		argObjs[i] = Object{
+			Value: arg,
+			Typ:   params.At(i).Type(),
+		}
+	}
+	resultObjs := callFunObj(funObj, argObjs)
+	results := make([]reflect.Value, len(resultObjs))
+	for i, resObj := range resultObjs {
+		results[i] = resObj.Value.(reflect.Value)
+	}
+	return results
+}
+
+// proxy is implemented by the proxy types, through their embedded *Proxy.
+type proxy interface {
+	proxy() *Proxy
+}
+
+func (p *Proxy) proxy() *Proxy {
+	return p
+}
+
+// object returns the value behind the proxy, with its type as of the latest input.
+func (p *Proxy) object() Object {
+	obj := p.obj
+	obj.Typ = p.interp.currentType(obj.Typ)
+	return obj
+}
+
+// proxyFor returns obj as it should be stored in a variable of type rtyp. If
+// rtyp is an interface type from an imported package, and obj holds a value of
+// a type declared in the session, the value is wrapped in a proxy. For the
+// empty interface, that's the proxy given by emptyIfaceProxy, so that fmt finds
+// the methods it looks for, or else a box. If rtyp stands for an interface
+// type declared in the session, any value that isn't an interface is wrapped
+// in a *Proxy. A value taken out of such an interface is unwrapped again.
+// Otherwise, obj is returned as it is. Like any value stored in an interface,
+// the proxied value is a copy.
+func (i *interp) proxyFor(obj Object, rtyp reflect.Type) Object {
+	val, ok := obj.Value.(reflect.Value)
+	if !ok || obj.Typ == nil || rtyp.Kind() != reflect.Interface || rtyp == recursiveFieldType {
+		return obj
+	}
+	if rtyp == sessionIfaceType {
+		if val.Kind() == reflect.Interface {
+			return obj
+		}
+		p := &Proxy{
+			obj:    copyObjs([]Object{obj})[0],
+			interp: i,
+		}
+		return Object{
+			Value: reflect.ValueOf(p),
+			Typ:   obj.Typ,
+		}
+	}
+	sessionTyped := val.Kind() != reflect.Interface && hasSessionType(obj.Typ)
+	if val.Type() == sessionIfaceType {
+		if val.IsNil() {
+			return Object{
+				Value: reflect.Zero(rtyp),
+				Typ:   obj.Typ,
+			}
+		}
+		if dynamicObj, ok := proxiedObj(val); ok {
+			obj, val = dynamicObj, dynamicObj.Value.(reflect.Value)
+			sessionTyped = hasSessionType(obj.Typ)
+		} else {
+			// The value came from native code, so its reflect type is its type
+			obj.Value, val = val.Elem(), val.Elem()
+		}
+	}
+	if val.Type().Implements(rtyp) && !sessionTyped {
+		return obj
+	}
+	makeProxy, ok := i.proxies[rtyp]
+	if !ok && rtyp.NumMethod() == 0 {
+		makeProxy, ok = i.emptyIfaceProxy(obj.Typ), true
+	}
+	if !ok {
+		return obj
+	}
+	p := &Proxy{
+		obj:    copyObjs([]Object{obj})[0],
+		interp: i,
+	}
+	proxyVal := reflect.New(rtyp).Elem()
+	proxyVal.Set(reflect.ValueOf(makeProxy(p)))
+	return Object{
+		Value: proxyVal,
+		Typ:   obj.Typ,
+	}
+}
+
+// emptyIfaceProxies are the interface types whose proxies are used for a value
+// of a type declared in the session that's stored in an empty interface, in
+// order of preference. They're the interfaces that fmt looks for, so that it
+// calls the methods of the value, as it would for a native value.
+var emptyIfaceProxies = []struct {
+	rtyp   reflect.Type
+	method string
+}{
+	{reflect.TypeOf((*error)(nil)).Elem(), "Error"},
+	{reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), "String"},
+}
+
+// emptyIfaceProxy returns the ProxyMaker for a value of type typ stored in an
+// empty interface: the proxy for the first of emptyIfaceProxies that typ
+// implements, or else a box.
+func (i *interp) emptyIfaceProxy(typ types.Type) ProxyMaker {
+	for _, iface := range emptyIfaceProxies {
+		makeProxy, ok := i.proxies[iface.rtyp]
+		if !ok {
+			continue
+		}
+		// The method must be a func() string
+		sel := lookupMethod(typ, iface.method)
+		if sel == nil {
+			continue
+		}
+		sig := sel.Type().(*types.Signature)
+		if sig.Params().Len() == 0 && sig.Results().Len() == 1 &&
+			types.Identical(sig.Results().At(0).Type(), types.Typ[types.String]) {
+			return makeProxy
+		}
+	}
+	return makeBox
+}
+
+// A box holds a value of a type declared in the session in an empty interface,
+// so that type assertions and type switches can tell the value's type from its
+// underlying type. Native functions are given the value rather than the box,
+// by unboxed.
+type box struct {
+	*Proxy
+}
+
+func makeBox(p *Proxy) interface{} {
+	return box{p}
+}
+
+// unboxed returns v with the value in each box it holds taken out, for a native
+// function, which can't tell a box from the value in it. Boxes are found in
+// interfaces and in the elements and fields of slices, arrays, maps and
+// structs, which are copied rather than changed. Pointers aren't followed.
+func unboxed(v reflect.Value) reflect.Value {
+	u, _ := unboxedIn(v, map[reference]bool{})
+	return u
+}
+
+// A reference identifies a slice or map that unboxedIn has already been
+// through, since either may hold itself.
+type reference struct {
+	ptr  uintptr
+	rtyp reflect.Type
+}
+
+// unboxedIn returns v unboxed, and whether it had any boxes, skipping the slices
+// and maps in seen.
+func unboxedIn(v reflect.Value, seen map[reference]bool) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() || !v.CanInterface() {
+			return v, false
+		}
+		elem, changed := v.Elem(), false
+		if b, ok := elem.Interface().(box); ok {
+			elem, changed = b.obj.Value.(reflect.Value), true
+		}
+		if u, ok := unboxedIn(elem, seen); ok {
+			elem, changed = u, true
+		}
+		if !changed {
+			return v, false
+		}
+		iface := reflect.New(v.Type()).Elem()
+		iface.Set(elem)
+		return iface, true
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		ref := reference{v.Pointer(), v.Type()}
+		if seen[ref] {
+			return v, false
+		}
+		seen[ref] = true
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if !mayHoldBox(v.Type().Elem()) {
+			return v, false
+		}
+		elems, changed := make([]reflect.Value, v.Len()), false
+		for j := range elems {
+			var ok bool
+			elems[j], ok = unboxedIn(v.Index(j), seen)
+			changed = changed || ok
+		}
+		if !changed {
+			return v, false
+		}
+		vCopy := reflect.New(v.Type()).Elem()
+		if v.Kind() == reflect.Slice {
+			vCopy.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		}
+		for j, elem := range elems {
+			vCopy.Index(j).Set(elem)
+		}
+		return vCopy, true
+	case reflect.Map:
+		if !mayHoldBox(v.Type().Key()) && !mayHoldBox(v.Type().Elem()) {
+			return v, false
+		}
+		keys, changed := v.MapKeys(), false
+		elems := make([]reflect.Value, len(keys))
+		for j, key := range keys {
+			var keyChanged, elemChanged bool
+			elems[j], elemChanged = unboxedIn(v.MapIndex(key), seen)
+			keys[j], keyChanged = unboxedIn(key, seen)
+			changed = changed || keyChanged || elemChanged
+		}
+		if !changed {
+			return v, false
+		}
+		vCopy := reflect.MakeMap(v.Type())
+		for j, key := range keys {
+			vCopy.SetMapIndex(key, elems[j])
+		}
+		return vCopy, true
+	case reflect.Struct:
+		var vCopy reflect.Value
+		for j := 0; j < v.NumField(); j++ {
+			// Unexported fields can't be set, and native code doesn't see them
+			if v.Type().Field(j).PkgPath != "" || !mayHoldBox(v.Type().Field(j).Type) {
+				continue
+			}
+			field, ok := unboxedIn(v.Field(j), seen)
+			if !ok {
+				continue
+			}
+			if !vCopy.IsValid() {
+				vCopy = reflect.New(v.Type()).Elem()
+				vCopy.Set(v)
+			}
+			vCopy.Field(j).Set(field)
+		}
+		if vCopy.IsValid() {
+			return vCopy, true
+		}
+	}
+	return v, false
+}
+
+// mayHoldBox reports whether a value of type rtyp may hold a box, other than
+// through a pointer. It only looks at the kind of rtyp, so that a slice of
+// numbers isn't gone through one number at a time.
+func mayHoldBox(rtyp reflect.Type) bool {
+	switch rtyp.Kind() {
+	case reflect.Interface, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// proxiedObj returns the Object behind the dynamic value of ifaceVal, if that
+// value is a proxy.
+func proxiedObj(ifaceVal reflect.Value) (Object, bool) {
+	dynamicVal := ifaceVal.Elem()
+	if !dynamicVal.IsValid() || !dynamicVal.CanInterface() {
+		return Object{}, false
+	}
+	p, ok := dynamicVal.Interface().(proxy)
+	if !ok {
+		return Object{}, false
+	}
+	return p.proxy().object(), true
+}
+
+// assertedValue returns the dynamic value of ifaceVal as a value of type rtyp,
+// once a type assertion to that type has succeeded. A value behind a proxy
+// stays in the proxy if it can, or else it gets the proxy for rtyp.
+func (i *interp) assertedValue(ifaceVal reflect.Value, rtyp reflect.Type) reflect.Value {
+	dynamicVal := ifaceVal.Elem()
+	obj, ok := proxiedObj(ifaceVal)
+	switch {
+	case !ok, rtyp.Kind() == reflect.Interface && dynamicVal.Type().Implements(rtyp):
+		return dynamicVal.Convert(rtyp)
+	case rtyp.Kind() == reflect.Interface:
+		proxyObj := i.proxyFor(obj, rtyp)
+		return proxyObj.Value.(reflect.Value)
+	}
+	return obj.Value.(reflect.Value).Convert(rtyp)
+}
+
+// valuesEqual reports whether lv and rv, values of identical types, are equal.
+// Values behind proxies are compared, rather than the proxies, and they're only
+// equal if their types are identical, too.
+func (i *interp) valuesEqual(lv, rv reflect.Value) bool {
+	if lv.Kind() == reflect.Interface {
+		lObj, lProxied := proxiedObj(lv)
+		rObj, rProxied := proxiedObj(rv)
+		switch {
+		case lProxied && rProxied:
+			return types.Identical(i.currentType(lObj.Typ), i.currentType(rObj.Typ)) &&
+				lObj.Value.(reflect.Value).Interface() == rObj.Value.(reflect.Value).Interface()
+		case lProxied || rProxied:
+			return false
+		}
+	}
+	return lv.Interface() == rv.Interface()
+}
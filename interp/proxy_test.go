@@ -0,0 +1,119 @@
+package interp
+
+import "testing"
+
+func TestProxy(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "sort.Interface",
+			inputs: []string{
+				"type byLen []string\n" +
+					"func (s byLen) Len() int { return len(s) }\n" +
+					"func (s byLen) Less(i, j int) bool { return len(s[i]) < len(s[j]) }\n" +
+					"func (s byLen) Swap(i, j int) { s[i], s[j] = s[j], s[i] }",
+				`xs := []string{"ccc", "a", "bb"}`,
+				"sort.Sort(byLen(xs))",
+				"fmt.Println(xs)",
+			},
+			want: "[a bb ccc]\n",
+		},
+		{
+			name: "fmt.Stringer",
+			inputs: []string{
+				"type Temp float64\nfunc (t Temp) String() string { return fmt.Sprintf(\"%.1f°C\", float64(t)) }",
+				"var s fmt.Stringer = Temp(21.5)",
+				"fmt.Println(s, s.String())",
+			},
+			want: "21.5°C 21.5°C\n",
+		},
+		{
+			name: "error",
+			inputs: []string{
+				"type NotFound struct{ Name string }\nfunc (e *NotFound) Error() string { return e.Name + \" not found\" }",
+				`find := func(name string) error { return &NotFound{name} }`,
+				`err := find("x")`,
+				"nf, ok := err.(*NotFound)",
+				"fmt.Println(err, ok, nf.Name)",
+				"switch e := err.(type) {\ncase fmt.Stringer:\n\tfmt.Println(\"stringer\")\ncase *NotFound:\n\tfmt.Println(\"switch\", e.Name)\n}",
+			},
+			want: "x not found true x\nswitch x\n",
+		},
+		{
+			name: "pointer receiver through a variable",
+			inputs: []string{
+				"type Counter struct{ n int }\nfunc (c *Counter) String() string { c.n++; return fmt.Sprint(c.n) }",
+				"c := &Counter{}",
+				"var s fmt.Stringer = c",
+				"fmt.Println(s, s)",
+				"fmt.Println(c.n)",
+			},
+			want: "1 2\n2\n",
+		},
+	})
+}
+
+func TestEmptyIface(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "assertions across inputs",
+			inputs: []string{
+				"type T int",
+				"func isT(x interface{}) bool { _, ok := x.(T); return ok }",
+				"fmt.Println(isT(T(1)), isT(1))",
+				"var x interface{} = T(2)",
+				"switch v := x.(type) {\ncase int:\n\tfmt.Println(\"int\", v)\ncase T:\n\tfmt.Println(\"T\", v)\n}",
+			},
+			want: "true false\nT 2\n",
+		},
+		{
+			name: "native functions get the values",
+			inputs: []string{
+				"type P struct{ X, Y int }",
+				"type T int",
+				`b, _ := json.Marshal(map[string]interface{}{"p": P{3, 4}, "ts": []interface{}{T(5)}})`,
+				"fmt.Println(string(b))",
+				"xs := []interface{}{T(1), P{1, 2}}",
+				"fmt.Println(xs...)",
+				"fmt.Println(xs)",
+			},
+			want: `{"p":{"X":3,"Y":4},"ts":[5]}` + "\n1 {1 2}\n[1 {1 2}]\n",
+		},
+		{
+			name: "fmt.Stringer and error",
+			inputs: []string{
+				"type Temp float64\nfunc (t Temp) String() string { return fmt.Sprintf(\"%.1f°C\", float64(t)) }",
+				"type Oops int\nfunc (o Oops) Error() string { return fmt.Sprint(\"oops \", int(o)) }",
+				"fmt.Println(Temp(3), Oops(2))",
+				"var x interface{} = Temp(3)",
+				"s, ok := x.(fmt.Stringer)",
+				"_, isErr := x.(error)",
+				"fmt.Println(s.String(), ok, isErr)",
+			},
+			want: "3.0°C oops 2\n3.0°C true false\n",
+		},
+		{
+			name: "equality",
+			inputs: []string{
+				"type T int",
+				"var x interface{} = T(1)",
+				"fmt.Println(x == T(1), x == 1, x == interface{}(T(1)))",
+				"f := func() interface{} { type L int; return L(1) }",
+				"g := func() interface{} { type L int; return L(1) }",
+				"fmt.Println(f() == g(), f() == f())",
+				"m := map[interface{}]int{T(1): 1}",
+				"fmt.Println(m[T(1)])",
+			},
+			want: "true false true\nfalse true\n1\n",
+		},
+		{
+			name: "panic and recover",
+			inputs: []string{
+				"type T int",
+				"f := func() (t T) { defer func() { t = recover().(T) }(); panic(T(5)) }",
+				"fmt.Println(f())",
+				"panic(T(2))",
+			},
+			want: "5\nerror: panic: 2\n",
+		},
+	})
+}
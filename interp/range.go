@@ -0,0 +1,184 @@
+package interp
+
+import (
+	"go/ast"
+	"go/token"
+	"log"
+	"reflect"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/types"
+)
+
+// runRangeStmt runs a "for" statement with a "range" clause.
+//
+// The range expression is evaluated once, before the iteration variables are
+// declared, so that it cannot refer to them. With "=", the iteration variables
+// are existing variables (or other assignable expressions) that are evaluated
+// and assigned to on each iteration, just like in an assignment statement.
+func (env *environ) runRangeStmt(stmt *ast.RangeStmt, label string) stmtResult {
+	// Evaluate the range expression in the enclosing environment
+	xObj := getTypedObject(env.Eval(stmt.X)[0])
+	xVal := xObj.Value.(reflect.Value)
+
+	// Set up scope and environment for the range clause
+	rangeEnv := &environ{
+		info:   env.info,
+		interp: env.interp,
+		scope:  env.info.Scopes[stmt],
+		parent: env,
+		objs:   map[string]Object{},
+	}
+
+	// Collect the iteration variable expressions. If there is a value, there is
+	// always a key, so the first expression is always the key.
+	var lhs []ast.Expr
+	if stmt.Key != nil {
+		lhs = append(lhs, stmt.Key)
+	}
+	if stmt.Value != nil {
+		lhs = append(lhs, stmt.Value)
+	}
+
+	var declVars []Object
+	if stmt.Tok == token.DEFINE {
+		declVars = rangeEnv.getDeclVars(lhs)
+	}
+
+	// iterate assigns the key and value to the iteration variables and runs the
+	// body once. It reports whether the loop is finished, along with the result
+	// the range statement should return in that case.
+	iterate := func(key, val Object) (bool, stmtResult) {
+		rhs := []Object{key, val}
+		switch stmt.Tok {
+		case token.DEFINE:
+			for i := range lhs {
+				env.assignObj(declVars[i], rhs[i])
+			}
+		case token.ASSIGN:
+			lhsObjs, mapIndexExprs := rangeEnv.getAssignmentLhs(lhs)
+			for i := range lhsObjs {
+				if mapIndexExprs[i] {
+					rangeEnv.assignMapIndex(lhs[i], rhs[i])
+				} else {
+					env.assignObj(lhsObjs[i], rhs[i])
+				}
+			}
+		}
+		stmtRes := rangeEnv.runStmt(stmt.Body, "", false)
+		switch res := stmtRes.(type) {
+		case nil:
+			return false, nil
+		case breakResult:
+			if string(res) == "" || string(res) == label {
+				return true, nil
+			}
+		case continueResult:
+			if string(res) == "" || string(res) == label {
+				return false, nil
+			}
+		}
+		return true, stmtRes
+	}
+
+	intObj := func(i int) Object {
+		return Object{
+			Value: reflect.ValueOf(i),
+			Typ:   types.Typ[types.Int],
+		}
+	}
+
+	xTyp := xObj.Typ.Underlying()
+	switch t := xTyp.(type) {
+	case *types.Basic:
+		// String: iterate over the Unicode code points, decoding them as UTF-8
+		s := xVal.String()
+		for i := 0; i < len(s); {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			runeObj := Object{
+				Value: reflect.ValueOf(r),
+				Typ:   types.Typ[types.Rune],
+			}
+			if done, stmtRes := iterate(intObj(i), runeObj); done {
+				return stmtRes
+			}
+			i += size
+		}
+	case *types.Pointer:
+		// Pointer to array. If there's no value variable, the pointer may be nil.
+		arrTyp := t.Elem().Underlying().(*types.Array)
+		n := int(arrTyp.Len())
+		if len(lhs) < 2 {
+			for i := 0; i < n; i++ {
+				if done, stmtRes := iterate(intObj(i), Object{}); done {
+					return stmtRes
+				}
+			}
+			return nil
+		}
+		arrVal := xVal.Elem()
+		if !arrVal.IsValid() {
+			// Nil pointer dereference!
+			panic(errNilDeref)
+		}
+		return rangeIndexed(arrVal, arrTyp.Elem(), iterate)
+	case *types.Array:
+		if len(lhs) == 2 {
+			// The values come from a copy of the array, since the range expression
+			// is evaluated just once, before the loop begins
+			xVal = copyObjs([]Object{xObj})[0].Value.(reflect.Value)
+		}
+		return rangeIndexed(xVal, t.Elem(), iterate)
+	case *types.Slice:
+		return rangeIndexed(xVal, t.Elem(), iterate)
+	case *types.Map:
+		// Entries removed during iteration must not be produced, so look up each
+		// key again right before its iteration.
+		for _, keyVal := range xVal.MapKeys() {
+			elemVal := xVal.MapIndex(keyVal)
+			if !elemVal.IsValid() {
+				continue
+			}
+			keyObj := Object{Value: keyVal, Typ: t.Key()}
+			elemObj := Object{Value: elemVal, Typ: t.Elem()}
+			if done, stmtRes := iterate(keyObj, elemObj); done {
+				return stmtRes
+			}
+		}
+	case *types.Chan:
+		// Receive values until the channel is closed
+		for {
+			recvVal, ok := xVal.Recv()
+			if !ok {
+				break
+			}
+			if done, stmtRes := iterate(Object{Value: recvVal, Typ: t.Elem()}, Object{}); done {
+				return stmtRes
+			}
+		}
+	default:
+		log.Fatalf("Unexpected type of range expression: %v", xObj.Typ)
+	}
+	return nil
+}
+
+// rangeIndexed iterates over the elements of a slice or array value in order.
+// The length is fixed when the iteration begins.
+func rangeIndexed(xVal reflect.Value, elemTyp types.Type,
+	iterate func(key, val Object) (bool, stmtResult)) stmtResult {
+	n := xVal.Len()
+	for i := 0; i < n; i++ {
+		keyObj := Object{
+			Value: reflect.ValueOf(i),
+			Typ:   types.Typ[types.Int],
+		}
+		elemObj := Object{
+			Value: xVal.Index(i),
+			Typ:   elemTyp,
+		}
+		if done, stmtRes := iterate(keyObj, elemObj); done {
+			return stmtRes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package interp
+
+import "testing"
+
+func TestRange(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "slice",
+			inputs: []string{
+				"s := make([]int, 3)",
+				"s[0], s[2] = 1, 3",
+				"for i, v := range s { fmt.Println(i, v) }",
+				"for i := range s { fmt.Println(i) }",
+				"for range s { fmt.Println(\"x\") }",
+			},
+			want: "0 1\n1 0\n2 3\n0\n1\n2\nx\nx\nx\n",
+		},
+		{
+			name: "string by rune offset",
+			inputs: []string{
+				`for i, c := range "héllo" { fmt.Println(i, c) }`,
+				`for i := range "é!" { fmt.Println(i) }`,
+				`for i, c := range "a\xffb" { fmt.Println(i, c) }`,
+			},
+			want: "0 104\n1 233\n3 108\n4 108\n5 111\n0\n2\n0 97\n1 65533\n2 98\n",
+		},
+		{
+			name: "map",
+			inputs: []string{
+				`m := make(map[string]int)`,
+				`m["a"], m["b"], m["c"] = 1, 2, 3`,
+				`sum, n := 0, 0`,
+				`for k, v := range m { sum += v; n += m[k] }`,
+				`fmt.Println(sum, n)`,
+			},
+			want: "6 6\n",
+		},
+		{
+			name: "channel until closed",
+			inputs: []string{
+				"ch := make(chan int, 3)",
+				"ch <- 4",
+				"ch <- 5",
+				"close(ch)",
+				"for x := range ch { fmt.Println(\"got\", x) }",
+			},
+			want: "got 4\ngot 5\n",
+		},
+		{
+			name: "assign to existing variables",
+			inputs: []string{
+				"s := make([]int, 3)",
+				"s[1] = 7",
+				"i, v := -1, -1",
+				"for i, v = range s { if i == 1 { break } }",
+				"fmt.Println(i, v)",
+				"m := make(map[string]int)",
+				`for _, m["z"] = range s {}`,
+				`fmt.Println(m["z"])`,
+			},
+			want: "1 7\n0\n",
+		},
+		{
+			name: "break, continue and return",
+			inputs: []string{
+				"s := make([]int, 4)",
+				"for i := range s { s[i] = i * i }",
+				"for _, v := range s { if v == 1 { continue }; if v == 9 { break }; fmt.Println(v) }",
+				"f := func() int { for _, v := range s { if v > 1 { return v } }; return -1 }",
+				"fmt.Println(f())",
+			},
+			want: "0\n4\n4\n",
+		},
+	})
+}
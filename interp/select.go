@@ -3,7 +3,6 @@ package interp
 import (
 	"go/ast"
 	"go/token"
-	"log"
 	"reflect"
 )
 
@@ -23,7 +22,8 @@ type selectCaseContext struct {
 	stmts []ast.Stmt  // statement list to execute if case is chosen
 }
 
-func (env *environ) runSelect(clauses []ast.Stmt, cases []reflect.SelectCase, ctxs []selectCaseContext) stmtResult {
+func (env *environ) runSelect(clauses []ast.Stmt, cases []reflect.SelectCase, ctxs []selectCaseContext,
+	label string) stmtResult {
 	chosen, recv, recvOK := reflect.Select(cases)
 	ctx := ctxs[chosen]
 	clause := clauses[chosen]
@@ -56,18 +56,14 @@ func (env *environ) runSelect(clauses []ast.Stmt, cases []reflect.SelectCase, ct
 			if mapIndexExprs[i] {
 				env.assignMapIndex(ctx.lhs[i], rhs[i])
 			} else {
-				assignObj(lhs[i], rhs[i])
+				env.assignObj(lhs[i], rhs[i])
 			}
 		}
 	}
 	// In any case, run the statement list
-	for _, stmt := range ctx.stmts {
-		// TODO: Handle label
-		stmtRes := caseEnv.runStmt(stmt, "", false)
-		if stmtRes != nil {
-			// TODO: Handle stmtRes
-			log.Fatal("return/break from select statement not yet implemented")
-		}
+	stmtRes := caseEnv.runStmtList(ctx.stmts, false)
+	if res, ok := stmtRes.(breakResult); ok && (string(res) == "" || string(res) == label) {
+		return nil
 	}
-	return nil
+	return stmtRes
 }
@@ -0,0 +1,98 @@
+package interp
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"reflect"
+
+	"golang.org/x/tools/go/types"
+)
+
+// evalSliceExpr evaluates a slice expression. Slicing a string gives a string,
+// and slicing an array (or a pointer to an array) gives a slice that shares the
+// array's storage. Bounds out of range cause a run-time panic, just like in Go.
+func (env *environ) evalSliceExpr(e *ast.SliceExpr, typ types.Type) Object {
+	xObj := getTypedObject(env.Eval(e.X)[0])
+	xVal := xObj.Value.(reflect.Value)
+
+	xTyp := xObj.Typ.Underlying()
+	if ptr, ok := xTyp.(*types.Pointer); ok {
+		if xVal.IsNil() {
+			panic(errNilDeref)
+		}
+		xVal = xVal.Elem()
+		xTyp = ptr.Elem().Underlying()
+	}
+
+	// The bounds are checked against the capacity, except for strings and
+	// arrays, which are reported as their length
+	var capacity int
+	capWord := "length"
+	switch xTyp.(type) {
+	case *types.Basic:
+		capacity = xVal.Len()
+	case *types.Slice:
+		capacity = xVal.Cap()
+		capWord = "capacity"
+	case *types.Array:
+		capacity = xVal.Len()
+	default:
+		log.Fatalf("Unexpected type of slice expression operand: %v", xObj.Typ)
+	}
+
+	// Evaluate the indices, which default to 0, the length and the capacity
+	low, high, max := 0, xVal.Len(), capacity
+	if e.Low != nil {
+		low = getInt(env.Eval(e.Low)[0])
+	}
+	if e.High != nil {
+		high = getInt(env.Eval(e.High)[0])
+	}
+	if e.Max != nil {
+		max = getInt(env.Eval(e.Max)[0])
+	}
+	if err := checkSliceBounds(low, high, max, capacity, e.Slice3, capWord); err != nil {
+		panic(err)
+	}
+
+	var val reflect.Value
+	switch {
+	case xVal.Kind() == reflect.String:
+		rtyp, _ := getReflectType(env.interp.typeMap, typ)
+		val = reflect.ValueOf(xVal.String()[low:high]).Convert(rtyp)
+	case e.Slice3:
+		val = xVal.Slice3(low, high, max)
+	default:
+		val = xVal.Slice(low, high)
+	}
+	return Object{
+		Value: val,
+		Typ:   typ,
+	}
+}
+
+// checkSliceBounds checks the indices of a slice expression, returning the same
+// error the Go runtime would panic with if they're out of range.
+func checkSliceBounds(low, high, max, capacity int, slice3 bool, capWord string) error {
+	if slice3 {
+		switch {
+		case max < 0 || max > capacity:
+			return runtimeError(fmt.Sprintf("slice bounds out of range [::%d] with %s %d", max, capWord, capacity))
+		case high < 0 || high > max:
+			return runtimeError(fmt.Sprintf("slice bounds out of range [:%d:%d]", high, max))
+		case low < 0 || low > high:
+			return runtimeError(fmt.Sprintf("slice bounds out of range [%d:%d:]", low, high))
+		}
+		return nil
+	}
+	switch {
+	case high < 0 || high > capacity:
+		return runtimeError(fmt.Sprintf("slice bounds out of range [:%d] with %s %d", high, capWord, capacity))
+	case low < 0:
+		return runtimeError(fmt.Sprintf("slice bounds out of range [%d:]", low))
+	case low > high:
+		return runtimeError(fmt.Sprintf("slice bounds out of range [%d:%d]", low, high))
+	}
+	return nil
+}
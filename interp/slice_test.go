@@ -0,0 +1,48 @@
+package interp
+
+import "testing"
+
+func TestSliceExpr(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "slices share storage",
+			inputs: []string{
+				"xs := []int{0, 1, 2, 3, 4}",
+				"ys := xs[1:3]\nys[0] = 10",
+				"fmt.Println(xs, ys, xs[:2], xs[3:], xs[:])",
+			},
+			want: "[0 10 2 3 4] [10 2] [0 10] [3 4] [0 10 2 3 4]\n",
+		},
+		{
+			name: "full slice expression",
+			inputs: []string{
+				"xs := []int{0, 1, 2, 3, 4}",
+				"ys := xs[1:2:3]",
+				"fmt.Println(ys, ys[:2])",
+			},
+			want: "[1] [1 2]\n",
+		},
+		{
+			name: "strings and arrays",
+			inputs: []string{
+				`s := "hello, world"`,
+				"a := [4]int{1, 2, 3, 4}\np := &a",
+				"fmt.Println(s[7:], s[:5], a[1:3], p[2:])",
+			},
+			want: "world hello [2 3] [3 4]\n",
+		},
+		{
+			name: "out of range",
+			inputs: []string{
+				"xs := []int{0, 1, 2}",
+				"fmt.Println(xs[1:5])",
+				"var i = 2",
+				"fmt.Println(xs[i:1])",
+				"fmt.Println(xs[:1:4])",
+			},
+			want: "error: panic: runtime error: slice bounds out of range [:5] with capacity 3\n" +
+				"error: panic: runtime error: slice bounds out of range [2:1]\n" +
+				"error: panic: runtime error: slice bounds out of range [::4] with capacity 3\n",
+		},
+	})
+}
@@ -18,10 +18,14 @@ type stmtResult interface {
 type returnResult []Object
 type breakResult string
 type continueResult string
+type fallthroughResult struct{}
+type gotoResult string
 
-func (r returnResult) stmtResult()   {}
-func (r breakResult) stmtResult()    {}
-func (r continueResult) stmtResult() {}
+func (r returnResult) stmtResult()      {}
+func (r breakResult) stmtResult()       {}
+func (r continueResult) stmtResult()    {}
+func (r fallthroughResult) stmtResult() {}
+func (r gotoResult) stmtResult()        {}
 
 func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResult {
 	switch stmt := stmt.(type) {
@@ -42,26 +46,30 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 		case token.CONTINUE:
 			return continueResult(label)
 		case token.GOTO:
-			log.Fatal("Goto statements not implemented")
+			return gotoResult(label)
 		case token.FALLTHROUGH:
-			log.Fatal("Fallthrough statements not implemented")
+			return fallthroughResult{}
 		}
 	case *ast.AssignStmt:
-		// First, get LHS
-		var lhs []Object
+		// First, get LHS, then evaluate RHS
+		var lhs, rhs []Object
 		var mapIndexExprs map[int]bool
 
 		switch stmt.Tok {
 		case token.DEFINE:
-			// Short variable declaration
+			// Short variable declaration. The new variables aren't in scope
+			// until after the statement, as in "x := x", so evaluate RHS first.
+			rhs = env.evalExprs(stmt.Rhs)
 			lhs = env.getDeclVars(stmt.Lhs)
 		default:
 			// Normal assignment or assignment operation (= or op=)
 			lhs, mapIndexExprs = env.getAssignmentLhs(stmt.Lhs)
+			rhs = env.evalExprs(stmt.Rhs)
+		}
+		if len(rhs) > 1 {
+			// The values may be variables that are assigned to, as in "a[i], a[j] = a[j], a[i]"
+			rhs = copyObjs(rhs)
 		}
-
-		// Second, evaluate RHS
-		rhs := env.evalExprs(stmt.Rhs)
 
 		// Do assignment operation if applicable
 		if stmt.Tok != token.DEFINE && stmt.Tok != token.ASSIGN {
@@ -79,7 +87,7 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 			if mapIndexExprs[i] {
 				env.assignMapIndex(stmt.Lhs[i], rhs[i])
 			} else {
-				assignObj(lhs[i], rhs[i])
+				env.assignObj(lhs[i], rhs[i])
 			}
 		}
 	case *ast.IncDecStmt:
@@ -118,10 +126,18 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 		} else {
 			env.evalFuncCall(stmt.Call, true)
 		}
+	case *ast.DeferStmt:
+		callKind := env.getCallExprKind(stmt.Call)
+		if callKind == builtinKind {
+			env.deferBuiltinCall(stmt.Call)
+		} else {
+			env.deferFuncCall(stmt.Call)
+		}
 	case *ast.SendStmt:
 		chanObj := env.Eval(stmt.Chan)[0]
 		sentObj := env.Eval(stmt.Value)[0]
 		chanVal := chanObj.Value.(reflect.Value)
+		sentObj = env.interp.proxyFor(sentObj, chanVal.Type().Elem())
 		sentVal := sentObj.Value.(reflect.Value)
 		chanVal.Send(sentVal)
 	case *ast.ForStmt:
@@ -166,6 +182,12 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 				forClauseEnv.runStmt(stmt.Post, "", false)
 			}
 		}
+	case *ast.RangeStmt:
+		return env.runRangeStmt(stmt, label)
+	case *ast.SwitchStmt:
+		return env.runSwitchStmt(stmt, label)
+	case *ast.TypeSwitchStmt:
+		return env.runTypeSwitchStmt(stmt, label)
 	case *ast.IfStmt:
 		// Set up scope and environment for the for statement
 		ifScope := env.scope
@@ -259,7 +281,7 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 				cases[i].Chan = chanObj.Value.(reflect.Value)
 			}
 		}
-		env.runSelect(clauses, cases, ctxs)
+		return env.runSelect(clauses, cases, ctxs, label)
 	case *ast.BlockStmt:
 		blockScope := env.info.Scopes[stmt]
 		blockEnv := &environ{
@@ -269,13 +291,46 @@ func (env *environ) runStmt(stmt ast.Stmt, label string, topLevel bool) stmtResu
 			parent: env,
 			objs:   map[string]Object{},
 		}
-		for _, st := range stmt.List {
-			if stmtRes := blockEnv.runStmt(st, "", false); stmtRes != nil {
-				return stmtRes
-			}
-		}
+		return blockEnv.runStmtList(stmt.List, false)
+	case *ast.DeclStmt:
+		env.runDecl(stmt.Decl.(*ast.GenDecl))
+	case *ast.LabeledStmt:
+		return env.runStmt(stmt.Stmt, stmt.Label.Name, topLevel)
+	case *ast.EmptyStmt:
+		// Nothing to do
 	default:
 		log.Fatalf("Unhandled statement type: %T", stmt)
 	}
 	return nil
 }
+
+// runStmtList runs a list of statements in env, stopping at the first statement
+// with a result. A "goto" whose label belongs to one of the statements in the
+// list continues from that statement instead. Any other "goto" must jump out of
+// the list, to a label in an enclosing one.
+func (env *environ) runStmtList(stmts []ast.Stmt, topLevel bool) stmtResult {
+	for i := 0; i < len(stmts); i++ {
+		stmtRes := env.runStmt(stmts[i], "", topLevel)
+		if label, ok := stmtRes.(gotoResult); ok {
+			if j := labeledStmtIndex(stmts, string(label)); j >= 0 {
+				i = j - 1
+				continue
+			}
+		}
+		if stmtRes != nil {
+			return stmtRes
+		}
+	}
+	return nil
+}
+
+// labeledStmtIndex returns the index of the statement in stmts with the given label,
+// or -1 if there is none.
+func labeledStmtIndex(stmts []ast.Stmt, label string) int {
+	for i, stmt := range stmts {
+		if labeledStmt, ok := stmt.(*ast.LabeledStmt); ok && labeledStmt.Label.Name == label {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package interp
+
+import "testing"
+
+func TestLabels(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "break and continue of an outer loop",
+			inputs: []string{
+				"outer:\nfor i := 0; i < 3; i++ {\n\tfor j := 0; j < 3; j++ {\n\t\tif j == 2 { continue outer }\n\t\tif i == 2 { break outer }\n\t\tfmt.Println(i, j)\n\t}\n}",
+			},
+			want: "0 0\n0 1\n1 0\n1 1\n",
+		},
+		{
+			name: "break out of range, select and switch",
+			inputs: []string{
+				"s := make([]int, 3)",
+				`L: for i := range s { switch { case i == 1: break L }; fmt.Println("range", i) }`,
+				"ch := make(chan int, 1)",
+				"ch <- 1",
+				`Sel: for { select { case v := <-ch: fmt.Println("recv", v); break Sel } }`,
+				`M: switch { case true: for { break M } }`,
+				`fmt.Println("end")`,
+			},
+			want: "range 0\nrecv 1\nend\n",
+		},
+	})
+}
+
+func TestGoto(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "top level",
+			inputs: []string{
+				"n := 0",
+				"loop:\nif n < 3 { n++; goto loop }\nfmt.Println(n)",
+			},
+			want: "3\n",
+		},
+		{
+			name: "forward and backward in a function",
+			inputs: []string{
+				`f := func() int { i := 0; again: i++; if i < 5 { goto again }; { goto done }; fmt.Println("skipped"); done: return i }`,
+				"fmt.Println(f())",
+			},
+			want: "5\n",
+		},
+		{
+			name: "label of an earlier input",
+			inputs: []string{
+				"loop:\nfor {\n\tbreak loop\n}",
+				"goto loop",
+			},
+			want: "error: input:5:7: label loop not declared\n",
+		},
+		{
+			name: "labels reused across inputs",
+			inputs: []string{
+				"L:\nfor i := 0; i < 3; i++ {\n\tfor j := 0; j < 3; j++ {\n\t\tif j == 1 { continue L }\n\t\tif i == 2 { break L }\n\t\tfmt.Println(i, j)\n\t}\n}",
+				"L:\nfor i := 0; i < 2; i++ {\n\tfmt.Println(\"again\", i)\n\tbreak L\n}",
+				"n := 0\nM:\nn++\nif n < 3 { goto M }\nfmt.Println(n)",
+				"n = 0\nM:\nn++\nif n < 2 { goto M }\nfmt.Println(\"M again\", n)",
+			},
+			want: "0 0\n1 0\nagain 0\n3\nM again 2\n",
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package interp
+
+import (
+	"go/ast"
+	"reflect"
+	"unsafe"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// structPkgPath is the package path given to unexported fields of struct types
+// built for the session. The reflect package requires one.
+const structPkgPath = "main"
+
+// recursiveField is the type of the fields of a struct type that refer back to
+// a named type that's being built, such as Next in
+// "type Node struct{ V int; Next *Node }". The reflect package can't build a
+// type that refers to itself, so such a field holds its value in an interface,
+// and fieldValue gives the value back its real type. A nil value is always
+// stored as a nil interface, so that struct values compare as they should.
+type recursiveField interface{}
+
+var recursiveFieldType = reflect.TypeOf((*recursiveField)(nil)).Elem()
+
+// structOf builds a reflect.Type for a struct type that isn't in typeMap, using
+// reflect.StructOf. Field names and tags are kept, so that packages such as
+// encoding/json work on values of the struct type just as they would on
+// values of a struct type compiled into the program. It returns nil if the
+// type of some field has no reflect.Type.
+func structOf(typeMap *typeutil.Map, typ *types.Struct, building map[*types.Named]bool) (reflect.Type, bool) {
+	fields := make([]reflect.StructField, typ.NumFields())
+	sim := false
+	for i := range fields {
+		f := typ.Field(i)
+		var rtyp reflect.Type
+		var fieldSim bool
+		if !holdsStruct(f.Type()) && refersTo(f.Type(), building, map[*types.Named]bool{}) {
+			rtyp = recursiveFieldType
+		} else {
+			rtyp, fieldSim = reflectTypeOf(typeMap, f.Type(), building)
+		}
+		if rtyp == nil {
+			return nil, false
+		}
+		sim = sim || fieldSim
+		fields[i] = reflect.StructField{
+			Name: f.Name(),
+			Type: rtyp,
+			Tag:  reflect.StructTag(typ.Tag(i)),
+		}
+		if !f.Exported() {
+			fields[i].PkgPath = structPkgPath
+		}
+		// The reflect package can't promote the methods of an embedded field, and
+		// refuses some such fields altogether, so only embed fields without
+		// methods. The type checker takes care of promoted fields either way.
+		if f.Anonymous() && f.Exported() && rtyp.NumMethod() == 0 &&
+			(rtyp.Kind() == reflect.Ptr || reflect.PtrTo(rtyp).NumMethod() == 0) {
+			fields[i].Anonymous = true
+		}
+	}
+	return reflect.StructOf(fields), sim
+}
+
+// refersTo reports whether typ refers to any of the named types in building,
+// other than through an interface. The named types that have already been
+// looked through are in seen.
+func refersTo(typ types.Type, building, seen map[*types.Named]bool) bool {
+	switch typ := typ.(type) {
+	case *types.Named:
+		if building[typ] {
+			return true
+		}
+		if seen[typ] || !isSessionType(typ) {
+			return false
+		}
+		seen[typ] = true
+		return refersTo(typ.Underlying(), building, seen)
+	case *types.Pointer:
+		return refersTo(typ.Elem(), building, seen)
+	case *types.Slice:
+		return refersTo(typ.Elem(), building, seen)
+	case *types.Array:
+		return refersTo(typ.Elem(), building, seen)
+	case *types.Chan:
+		return refersTo(typ.Elem(), building, seen)
+	case *types.Map:
+		return refersTo(typ.Key(), building, seen) || refersTo(typ.Elem(), building, seen)
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if refersTo(typ.Field(i).Type(), building, seen) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return tupleRefersTo(typ.Params(), building, seen) || tupleRefersTo(typ.Results(), building, seen)
+	}
+	return false
+}
+
+// holdsStruct reports whether typ is a struct type, or an array type whose
+// elements are structs. A field of such a type that refers back to its struct
+// type does so through a field of its own, which is the one that's made a
+// recursive field, so the value of the struct stays in place.
+func holdsStruct(typ types.Type) bool {
+	for {
+		switch t := typ.Underlying().(type) {
+		case *types.Struct:
+			return true
+		case *types.Array:
+			typ = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+// tupleRefersTo reports whether any of the types in tuple refers to any of the
+// named types in building, as refersTo does.
+func tupleRefersTo(tuple *types.Tuple, building, seen map[*types.Named]bool) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if refersTo(tuple.At(i).Type(), building, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalField evaluates expr, which selects a field, giving the field as it's
+// stored in the struct. Unlike Eval, it leaves the value of a recursive field in
+// its interface, so that the field can be assigned to.
+func (env *environ) evalField(expr *ast.SelectorExpr) reflect.Value {
+	xo := env.Eval(expr.X)[0]
+	return fieldByIndex(xo.Value.(reflect.Value), env.info.Selections[expr].Index())
+}
+
+// fieldByIndex returns the nested field of the struct value v given by index.
+// If v is a pointer to a struct, it's dereferenced first, as are the embedded
+// pointers along the way. Unlike reflect.Value.FieldByIndex, it gives full
+// access to unexported fields, since the interpreted code may use the
+// unexported fields of its own struct types.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	if !v.CanAddr() && v.Kind() != reflect.Ptr {
+		// Copy the struct, so the field is addressable
+		vCopy := reflect.New(v.Type()).Elem()
+		vCopy.Set(v)
+		v = vCopy
+	}
+	for _, i := range index {
+		if v.Type() == recursiveFieldType {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				// Nil pointer dereference!
+				panic(errNilDeref)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+		if !v.CanInterface() {
+			v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+		}
+	}
+	return v
+}
+
+// fieldValue returns the value of field, a field returned by fieldByIndex. The
+// value of a recursive field is given back its real type, rtyp.
+func fieldValue(field reflect.Value, rtyp reflect.Type) reflect.Value {
+	if field.Type() != recursiveFieldType || rtyp == recursiveFieldType {
+		return field
+	}
+	if field.IsNil() {
+		return reflect.Zero(rtyp)
+	}
+	return field.Elem()
+}
+
+// setRecursiveField assigns v to field, a recursive field. A nil v is stored as
+// a nil interface.
+func setRecursiveField(field, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		if v.IsNil() {
+			v = reflect.Zero(recursiveFieldType)
+		}
+	}
+	field.Set(v)
+}
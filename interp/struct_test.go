@@ -0,0 +1,171 @@
+package interp
+
+import "testing"
+
+func TestStruct(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "fields",
+			inputs: []string{
+				"type Point struct{ X, Y int }",
+				"var p Point\np.X = 3\np.Y = 4",
+				`fmt.Printf("%+v %d\n", p, p.X*p.Y)`,
+				"pp := &p\npp.X = 5",
+				"fmt.Println(p.X, pp.Y)",
+			},
+			want: "{X:3 Y:4} 12\n5 4\n",
+		},
+		{
+			name: "tags and json",
+			inputs: []string{
+				"type User struct {\n\tName  string `json:\"name\"`\n\tAge   int    `json:\"age,omitempty\"`\n\tlogin string\n}",
+				`var u User
+u.Name = "ann"
+u.login = "a"`,
+				"b, err := json.Marshal(u)",
+				"fmt.Println(string(b), err, u.login)",
+			},
+			want: "{\"name\":\"ann\"} <nil> a\n",
+		},
+		{
+			name: "anonymous and nested",
+			inputs: []string{
+				"type Line struct{ From, To struct{ X, Y int } }",
+				"var l Line\nl.To.Y = 2",
+				"var s struct{ A string }\ns.A = \"x\"",
+				`fmt.Printf("%+v %+v\n", l, s)`,
+			},
+			want: "{From:{X:0 Y:0} To:{X:0 Y:2}} {A:x}\n",
+		},
+		{
+			name: "type kept across inputs",
+			inputs: []string{
+				"type P struct{ N int }",
+				"var a P",
+				"var b P\nb.N = 1",
+				"a = b",
+				"fmt.Println(a == b, a.N)",
+			},
+			want: "true 1\n",
+		},
+		{
+			name: "nil pointer",
+			inputs: []string{
+				"type P struct{ N int }",
+				"var p *P",
+				"fmt.Println(p.N)",
+			},
+			want: "error: panic: runtime error: invalid memory address or nil pointer dereference\n",
+		},
+	})
+}
+
+func TestRecursiveStruct(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "linked list",
+			inputs: []string{
+				"type Node struct{ V int; Next *Node }",
+				"var a, b Node\na.V, b.V = 1, 2\na.Next = &b",
+				"fmt.Println(a.Next.V, a.Next.Next == nil, b.Next)",
+				"for n := &a; ; n = n.Next {\n\tfmt.Print(n.V, \" \")\n\tif n.Next == nil {\n\t\tbreak\n\t}\n}",
+				"b.Next = &a\nb.Next = nil",
+				"fmt.Println(b.Next == nil)",
+			},
+			want: "2 true <nil>\n1 2 true\n",
+		},
+		{
+			name: "json and %+v",
+			inputs: []string{
+				"type Tree struct {\n\tName string `json:\"name\"`\n\tKids []Tree `json:\"kids\"`\n\tUp   *Tree  `json:\"-\"`\n}",
+				"var leaf, root Tree\nleaf.Name = \"leaf\"\nroot.Name = \"root\"",
+				"root.Kids = make([]Tree, 1)\nroot.Kids[0] = leaf\nroot.Kids[0].Up = &root",
+				"b, err := json.Marshal(root)",
+				"fmt.Println(string(b), err)",
+				"fmt.Println(root.Kids[0].Up.Name)",
+				`fmt.Printf("%+v\n", leaf)`,
+			},
+			want: "{\"name\":\"root\",\"kids\":[{\"name\":\"leaf\",\"kids\":null}]} <nil>\nroot\n{Name:leaf Kids:<nil> Up:<nil>}\n",
+		},
+		{
+			name: "equality",
+			inputs: []string{
+				"type Node struct{ V int; Next *Node }",
+				"var a, b Node",
+				"a.Next = nil",
+				"fmt.Println(a == b)",
+			},
+			want: "true\n",
+		},
+		{
+			name: "through a struct field",
+			inputs: []string{
+				"type A struct {\n\tb  B\n\tbs [2]B\n\tn  int\n}\ntype B struct {\n\ta *A\n\tm int\n}",
+				"var x A",
+				"x.b.m, x.bs[1].m = 3, 4",
+				"x.b.a = &x",
+				"fmt.Println(x.b.m, x.bs[1].m, x.b.a.n, x.b.a == &x)",
+			},
+			want: "3 4 0 true\n",
+		},
+		{
+			name: "other recursive types",
+			inputs: []string{
+				"type L []L",
+				"fmt.Println(1)",
+			},
+			want: "error: recursive type L is only supported through the fields of a struct\n1\n",
+		},
+	})
+}
+
+func TestEmbedded(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "promoted fields",
+			inputs: []string{
+				"type A struct{ X int }\ntype B struct{ *A }\ntype C struct{ B }",
+				"c := C{B{&A{1}}}",
+				"c.X = 5",
+				"fmt.Println(c.X, c.A.X, c.B.A.X)",
+				"var nilC C",
+				"fmt.Println(nilC.X)",
+			},
+			want: "5 5 5\nerror: panic: runtime error: invalid memory address or nil pointer dereference\n",
+		},
+		{
+			name: "promoted methods",
+			inputs: []string{
+				"type Base struct{ n int }\nfunc (b *Base) Inc() { b.n++ }\nfunc (b Base) N() int { return b.n }",
+				"type D struct{ *Base }",
+				"d := D{&Base{}}",
+				"d.Inc()\nd.Inc()",
+				"fmt.Println(d.N())",
+				"type Counter interface {\n\tInc()\n\tN() int\n}",
+				"var c Counter = d",
+				"c.Inc()",
+				"fmt.Println(c.N(), d.N(), D.N(d))",
+			},
+			want: "2\n3 3 3\n",
+		},
+		{
+			name: "imported types",
+			inputs: []string{
+				"type W struct{ *bytes.Buffer }",
+				`w := W{bytes.NewBufferString("ab")}`,
+				`w.WriteString("cd")`,
+				"fmt.Println(w.String(), w.Len())",
+			},
+			want: "abcd 4\n",
+		},
+		{
+			name: "through a field that refers back to its struct type",
+			inputs: []string{
+				"type A struct{ *B }\ntype B struct {\n\t*A\n\tV int\n}\nfunc (b *B) Get() int { return b.V }",
+				"a := A{&B{V: 7}}",
+				"fmt.Println(a.Get(), a.V)",
+			},
+			want: "7 7\n",
+		},
+	})
+}
@@ -0,0 +1,200 @@
+package interp
+
+import (
+	"go/ast"
+	"log"
+	"reflect"
+
+	"golang.org/x/tools/go/types"
+)
+
+// runSwitchStmt runs an expression switch statement.
+//
+// The tag is evaluated once. Then the case expressions are evaluated left to
+// right and top to bottom, and the first one equal to the tag chooses the clause
+// to run. If no case matches, the "default" clause runs, wherever it appears.
+func (env *environ) runSwitchStmt(stmt *ast.SwitchStmt, label string) stmtResult {
+	// Set up scope and environment for the switch statement
+	switchEnv := &environ{
+		info:   env.info,
+		interp: env.interp,
+		scope:  env.info.Scopes[stmt],
+		parent: env,
+		objs:   map[string]Object{},
+	}
+	if stmt.Init != nil {
+		switchEnv.runStmt(stmt.Init, "", false)
+	}
+
+	// A missing tag is equivalent to the boolean value true.
+	// An untyped constant tag is converted to its default type.
+	var tagObj Object
+	if stmt.Tag != nil {
+		tagObj = getTypedObject(switchEnv.Eval(stmt.Tag)[0])
+	} else {
+		tagObj = Object{
+			Value: reflect.ValueOf(true),
+			Typ:   types.Typ[types.Bool],
+		}
+	}
+
+	clauses := stmt.Body.List
+	chosen := -1
+	defaultIndex := -1
+findClause:
+	for i, clause := range clauses {
+		clause := clause.(*ast.CaseClause)
+		if clause.List == nil {
+			defaultIndex = i
+			continue
+		}
+		for _, expr := range clause.List {
+			caseObj := switchEnv.Eval(expr)[0]
+			equalObj := operatorEqual(switchEnv, tagObj, caseObj, types.Typ[types.Bool])
+			if equalObj.Value.(reflect.Value).Bool() {
+				chosen = i
+				break findClause
+			}
+		}
+	}
+	if chosen < 0 {
+		chosen = defaultIndex
+	}
+	if chosen < 0 {
+		// No case matched and there's no default clause
+		return nil
+	}
+	return switchEnv.runCaseClauses(clauses, chosen, label)
+}
+
+// runCaseClauses runs the body of the chosen clause of a switch statement in
+// the clause's own scope. As long as a clause ends in a "fallthrough" statement,
+// the body of the next clause in the source runs, too.
+func (env *environ) runCaseClauses(clauses []ast.Stmt, chosen int, label string) stmtResult {
+	for _, clause := range clauses[chosen:] {
+		clause := clause.(*ast.CaseClause)
+		caseEnv := &environ{
+			info:   env.info,
+			interp: env.interp,
+			scope:  env.info.Scopes[clause],
+			parent: env,
+			objs:   map[string]Object{},
+		}
+		stmtRes := caseEnv.runStmtList(clause.Body, false)
+		switch res := stmtRes.(type) {
+		case fallthroughResult:
+			continue
+		case breakResult:
+			if string(res) == "" || string(res) == label {
+				return nil
+			}
+		}
+		return stmtRes
+	}
+	return nil
+}
+
+// runTypeSwitchStmt runs a type switch statement.
+//
+// The cases are checked top to bottom against the dynamic type of the operand,
+// and the first matching one chooses the clause to run. If the switch declares
+// a variable, each clause gets its own copy of it, whose type the type checker
+// records as the clause's implicit object: the case type for a clause listing
+// exactly one type, and the operand's type otherwise.
+func (env *environ) runTypeSwitchStmt(stmt *ast.TypeSwitchStmt, label string) stmtResult {
+	// Set up scope and environment for the switch statement
+	switchEnv := &environ{
+		info:   env.info,
+		interp: env.interp,
+		scope:  env.info.Scopes[stmt],
+		parent: env,
+		objs:   map[string]Object{},
+	}
+	if stmt.Init != nil {
+		switchEnv.runStmt(stmt.Init, "", false)
+	}
+
+	// Get the type assertion, and whether it's in a short variable declaration
+	var assertExpr *ast.TypeAssertExpr
+	declaresVar := false
+	switch assign := stmt.Assign.(type) {
+	case *ast.ExprStmt:
+		assertExpr = assign.X.(*ast.TypeAssertExpr)
+	case *ast.AssignStmt:
+		assertExpr = assign.Rhs[0].(*ast.TypeAssertExpr)
+		declaresVar = true
+	}
+	xObj := switchEnv.Eval(assertExpr.X)[0]
+	xVal := xObj.Value.(reflect.Value)
+
+	clauses := stmt.Body.List
+	var chosen *ast.CaseClause
+	var boundObj Object
+findClause:
+	for _, clause := range clauses {
+		clause := clause.(*ast.CaseClause)
+		if clause.List == nil {
+			continue
+		}
+		for _, expr := range clause.List {
+			if switchEnv.info.Types[expr].Type == types.Typ[types.UntypedNil] {
+				// The "nil" case matches a nil interface value
+				if xVal.IsNil() {
+					chosen = clause
+					boundObj = xObj
+					break findClause
+				}
+				continue
+			}
+			caseTyp := switchEnv.info.TypeOf(expr)
+			caseRtyp, sim := getReflectType(switchEnv.interp.typeMap, caseTyp)
+			if caseRtyp == nil {
+				log.Fatalf("Couldn't get reflect type: %v", caseTyp)
+			}
+			if switchEnv.interp.dynamicTypeMatches(xVal, caseTyp, caseRtyp) {
+				chosen = clause
+				if len(clause.List) == 1 {
+					boundObj = Object{
+						Value: switchEnv.interp.assertedValue(xVal, caseRtyp),
+						Typ:   caseTyp,
+						Sim:   sim,
+					}
+				} else {
+					// The variable keeps the operand's type
+					boundObj = xObj
+				}
+				break findClause
+			}
+		}
+	}
+	if chosen == nil {
+		// Look for a default clause
+		for _, clause := range clauses {
+			if clause := clause.(*ast.CaseClause); clause.List == nil {
+				chosen = clause
+				boundObj = xObj
+			}
+		}
+	}
+	if chosen == nil {
+		// No case matched and there's no default clause
+		return nil
+	}
+
+	caseEnv := &environ{
+		info:   switchEnv.info,
+		interp: switchEnv.interp,
+		scope:  switchEnv.info.Scopes[chosen],
+		parent: switchEnv,
+		objs:   map[string]Object{},
+	}
+	if declaresVar {
+		varInfo := switchEnv.info.Implicits[chosen].(*types.Var)
+		caseEnv.addVar(varInfo, nil, boundObj)
+	}
+	stmtRes := caseEnv.runStmtList(chosen.Body, false)
+	if res, ok := stmtRes.(breakResult); ok && (string(res) == "" || string(res) == label) {
+		return nil
+	}
+	return stmtRes
+}
@@ -0,0 +1,84 @@
+package interp
+
+import "testing"
+
+func TestSwitch(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "case lists, default and fallthrough",
+			inputs: []string{
+				"x := 2",
+				`switch x { case 1: fmt.Println("one"); case 2, 3: fmt.Println("two or three"); fallthrough; default: fmt.Println("default"); case 4: fmt.Println("four") }`,
+				`switch x { default: fmt.Println("d"); fallthrough; case 7: fmt.Println("seven") }`,
+				`switch x { case 5: fmt.Println("five") }`,
+			},
+			want: "two or three\ndefault\nd\nseven\n",
+		},
+		{
+			name: "tagless with init",
+			inputs: []string{
+				"x := 2",
+				`switch y := x * 2; { case y > 10: fmt.Println("big"); default: fmt.Println("small", y) }`,
+			},
+			want: "small 4\n",
+		},
+		{
+			name: "break and continue",
+			inputs: []string{
+				`switch { case true: fmt.Println("a"); break; fmt.Println("b") }`,
+				`for i := 0; i < 3; i++ { switch i { case 1: continue }; fmt.Println("i", i) }`,
+			},
+			want: "a\ni 0\ni 2\n",
+		},
+		{
+			name: "interface and constant tags",
+			inputs: []string{
+				"e := interface{}(5)",
+				`switch e { case "a": fmt.Println("a"); case 5: fmt.Println("five"); case nil: fmt.Println("nil") }`,
+				"f := 2.5",
+				"switch f { case 1: fmt.Println(1); case 2.5: fmt.Println(2.5) }",
+				`switch "x" { case "x": fmt.Println("x") }`,
+			},
+			want: "five\n2.5\nx\n",
+		},
+	})
+}
+
+func TestTypeSwitch(t *testing.T) {
+	runTests(t, []runTest{
+		{
+			name: "binding form",
+			inputs: []string{
+				`f := func(e interface{}) { switch v := e.(type) { case nil: fmt.Println("nil", v); case int: fmt.Println("int", v+1); case string, bool: fmt.Println("string or bool", v); case error: fmt.Println("error", v); case fmt.Stringer: fmt.Println("stringer", v); default: fmt.Println("default", v) } }`,
+				"f(nil)",
+				"f(3)",
+				`f("x")`,
+				"f(true)",
+				`f(errors.New("boom"))`,
+				"f(2.5)",
+				`f(bytes.NewBufferString("buf"))`,
+			},
+			want: "nil <nil>\nint 4\nstring or bool x\nstring or bool true\nerror boom\ndefault 2.5\nstringer buf\n",
+		},
+		{
+			name: "without binding",
+			inputs: []string{
+				"g := func(e interface{}) int { switch e.(type) { case int: return 1; case float64: break; default: return 2 }; return 0 }",
+				`fmt.Println(g(1), g(1.5), g("s"))`,
+			},
+			want: "1 0 2\n",
+		},
+		{
+			name: "type assertions",
+			inputs: []string{
+				`e := interface{}(bytes.NewBufferString("q"))`,
+				"s, ok := e.(fmt.Stringer)",
+				"n, ok2 := e.(int)",
+				"z := interface{}(nil)",
+				"_, ok3 := z.(fmt.Stringer)",
+				"fmt.Println(s, ok, n, ok2, ok3)",
+			},
+			want: "q true 0 false false\n",
+		},
+	})
+}
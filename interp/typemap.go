@@ -1,8 +1,10 @@
 package interp
 
 import (
+	"fmt"
 	"log"
 	"reflect"
+	"sync"
 
 	"golang.org/x/tools/go/types"
 	"golang.org/x/tools/go/types/typeutil"
@@ -12,8 +14,11 @@ import (
 //   * slice types
 //   * chan types
 //   * map types
-//   * array types (must be simulated)
 
+var emptyIfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// simFuncType is the type of a simulated function, which represents a function
+// whose signature reflect.FuncOf can't build.
 var simFuncType reflect.Type
 
 func init() {
@@ -36,47 +41,214 @@ func getReflectDir(dir types.ChanDir) reflect.ChanDir {
 	return rdir
 }
 
+// typeMapMu guards the typeMap, which getReflectType adds to as it builds new
+// types, possibly on many goroutines at once.
+var typeMapMu sync.Mutex
+
 func getReflectType(typeMap *typeutil.Map, typ types.Type) (reflect.Type, bool) {
+	return reflectTypeOf(typeMap, typ, map[*types.Named]bool{})
+}
+
+// reflectTypeOf does the work of getReflectType. The named types that are in
+// the middle of being built are in building.
+func reflectTypeOf(typeMap *typeutil.Map, typ types.Type, building map[*types.Named]bool) (reflect.Type, bool) {
+	typeMapMu.Lock()
 	rt := typeMap.At(typ)
+	typeMapMu.Unlock()
 	if rt == nil {
-		// If it's a function type that isn't in typeMap, use a simulated function
 		switch typ := typ.(type) {
 		case *types.Signature:
-			return simFuncType, true
+			return funcOf(typeMap, typ, building)
 		case *types.Pointer:
-			t, _ := getReflectType(typeMap, typ.Elem())
+			t, _ := reflectTypeOf(typeMap, typ.Elem(), building)
 			if t != nil {
 				return reflect.PtrTo(t), false
 			}
 		case *types.Slice:
-			elem, _ := getReflectType(typeMap, typ.Elem())
+			elem, _ := reflectTypeOf(typeMap, typ.Elem(), building)
 			if elem != nil {
 				return reflect.SliceOf(elem), false
 			}
 		case *types.Array:
-			elem, _ := getReflectType(typeMap, typ.Elem())
+			elem, sim := reflectTypeOf(typeMap, typ.Elem(), building)
 			if elem != nil {
-				return reflect.SliceOf(elem), true
+				t := reflect.ArrayOf(int(typ.Len()), elem)
+				setReflectType(typeMap, typ, t, sim)
+				return t, sim
 			}
 		case *types.Chan:
-			elem, _ := getReflectType(typeMap, typ.Elem())
+			elem, _ := reflectTypeOf(typeMap, typ.Elem(), building)
 			dir := typ.Dir()
 			rdir := getReflectDir(dir)
 			if elem != nil {
 				return reflect.ChanOf(rdir, elem), false
 			}
 		case *types.Map:
-			key, _ := getReflectType(typeMap, typ.Key())
-			elem, _ := getReflectType(typeMap, typ.Elem())
+			key, _ := reflectTypeOf(typeMap, typ.Key(), building)
+			elem, _ := reflectTypeOf(typeMap, typ.Elem(), building)
 			if key != nil && elem != nil {
 				return reflect.MapOf(key, elem), false
 			}
+		case *types.Struct:
+			t, sim := structOf(typeMap, typ, building)
+			if t != nil {
+				setReflectType(typeMap, typ, t, sim)
+				return t, sim
+			}
+		case *types.Interface:
+			// The interface types the reflect package knows about are in typeMap
+			if typ.NumMethods() == 0 {
+				return emptyIfaceType, false
+			}
+			return sessionIfaceType, false
+		case *types.Named:
+			// The reflect package can't create named types, so a named type declared
+			// in the session is represented by its underlying type. An interface
+			// type with methods is represented by sessionIface, even if there's a
+			// native interface type with the same methods.
+			if isSessionType(typ) {
+				if iface, ok := typ.Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+					return sessionIfaceType, false
+				}
+				if building[typ] {
+					// structOf builds struct types that refer back to themselves,
+					// but there's no way to build any other such type
+					panic(unsupportedError(fmt.Sprintf("recursive type %v is only supported through the fields of a struct", typ)))
+				}
+				building[typ] = true
+				t, sim := reflectTypeOf(typeMap, typ.Underlying(), building)
+				delete(building, typ)
+				if t != nil {
+					setReflectType(typeMap, typ, t, sim)
+				}
+				return t, sim
+			}
 		}
 		return nil, false
 	}
 	return rt.(reflect.Type), false
 }
 
+// funcOf returns the reflect.Type of a function with signature sig. If any of
+// its parameter or result types can't be represented, or are simulated
+// themselves, the function is simulated. So is a function that refers back to
+// a named type that's being built, as in "type F func(F)".
+func funcOf(typeMap *typeutil.Map, sig *types.Signature, building map[*types.Named]bool) (reflect.Type, bool) {
+	if refersTo(sig, building, map[*types.Named]bool{}) {
+		return simFuncType, true
+	}
+	tupleTypes := func(tuple *types.Tuple) []reflect.Type {
+		rtyps := make([]reflect.Type, tuple.Len())
+		for i := range rtyps {
+			t, sim := reflectTypeOf(typeMap, tuple.At(i).Type(), building)
+			if t == nil || sim {
+				return nil
+			}
+			rtyps[i] = t
+		}
+		return rtyps
+	}
+	in := tupleTypes(sig.Params())
+	out := tupleTypes(sig.Results())
+	if in == nil || out == nil {
+		return simFuncType, true
+	}
+	t := reflect.FuncOf(in, out, sig.Variadic())
+	setReflectType(typeMap, sig, t, false)
+	return t, false
+}
+
+// setReflectType caches the reflect.Type built for typ, so it's only built once.
+// Each input is type checked anew, so a named type declared in the session is
+// a different types.Type in each input. But the reflect package gives the same
+// reflect.Type for each, so values keep their type across inputs. Simulated
+// types aren't cached, since typeMap can't record that they're simulated.
+func setReflectType(typeMap *typeutil.Map, typ types.Type, rtyp reflect.Type, sim bool) {
+	if sim {
+		return
+	}
+	typeMapMu.Lock()
+	typeMap.Set(typ, rtyp)
+	typeMapMu.Unlock()
+}
+
+// isSessionType reports whether typ is a named type declared in the session,
+// rather than in an imported package.
+func isSessionType(typ *types.Named) bool {
+	pkg := typ.Obj().Pkg()
+	return pkg != nil && pkg.Path() == ""
+}
+
+// hasSessionType reports whether typ is, or is made from, a type declared in the
+// session or an interface type with methods. The reflect type of a value of
+// such a type doesn't tell it apart from other types with the same
+// representation.
+func hasSessionType(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.Named:
+		return isSessionType(t)
+	case *types.Interface:
+		return t.NumMethods() > 0
+	case *types.Pointer:
+		return hasSessionType(t.Elem())
+	case *types.Slice:
+		return hasSessionType(t.Elem())
+	case *types.Array:
+		return hasSessionType(t.Elem())
+	case *types.Chan:
+		return hasSessionType(t.Elem())
+	case *types.Map:
+		return hasSessionType(t.Key()) || hasSessionType(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasSessionType(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return tupleHasSessionType(t.Params()) || tupleHasSessionType(t.Results())
+	}
+	return false
+}
+
+func tupleHasSessionType(tuple *types.Tuple) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if hasSessionType(tuple.At(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// currentType returns the type that typ stands for as of the latest input. Each
+// input is type checked anew, so a named type declared at package level in the
+// session is a new types.Type each time, but values keep the type they were
+// made with. Such a type is found by its name, since declaring it again
+// replaces it. Types declared in a function are left as they are.
+func (i *interp) currentType(typ types.Type) types.Type {
+	switch t := typ.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if !isSessionType(t) || i.pkg == nil || obj.Parent() != obj.Pkg().Scope() {
+			return typ
+		}
+		if cur, ok := i.pkg.Scope().Lookup(obj.Name()).(*types.TypeName); ok {
+			return cur.Type()
+		}
+	case *types.Pointer:
+		return types.NewPointer(i.currentType(t.Elem()))
+	case *types.Slice:
+		return types.NewSlice(i.currentType(t.Elem()))
+	case *types.Array:
+		return types.NewArray(i.currentType(t.Elem()), t.Len())
+	case *types.Map:
+		return types.NewMap(i.currentType(t.Key()), i.currentType(t.Elem()))
+	case *types.Chan:
+		return types.NewChan(t.Dir(), i.currentType(t.Elem()))
+	}
+	return typ
+}
+
 func addBasicTypes(typeMap *typeutil.Map) {
 	// bool
 	var xBool bool
@@ -134,7 +306,6 @@ func addBasicTypes(typeMap *typeutil.Map) {
 	typEmptyStruct := types.NewStruct([]*types.Var{}, []string{})
 	typeMap.Set(typEmptyStruct, reflect.TypeOf(xEmptyStruct))
 	// interface{}
-	var xEmptyInterface interface{}
 	typEmptyInterface := types.NewInterface([]*types.Func{}, []*types.Named{})
-	typeMap.Set(typEmptyInterface, reflect.TypeOf(&xEmptyInterface).Elem())
+	typeMap.Set(typEmptyInterface, emptyIfaceType)
 }
@@ -188,7 +188,9 @@ func writeType(buf *bytes.Buffer, this *types.Package, typ types.Type, visited [
 	case *types.Named:
 		s := "<Named w/o object>"
 		if obj := t.Obj(); obj != nil {
-			if pkg := obj.Pkg(); pkg != nil && pkg != this {
+			// Named types declared in the session (whose package has an empty path)
+			// are printed unqualified, the way the user wrote them.
+			if pkg := obj.Pkg(); pkg != nil && pkg != this && pkg.Path() != "" {
 				buf.WriteString(pkg.Name())
 				buf.WriteByte('.')
 			}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"text/template"
 
@@ -30,6 +31,7 @@ type Package struct {
 	Name    string
 	Types   []Type
 	Objects []Object
+	Proxies []Proxy
 	typeMap *typeutil.Map
 }
 
@@ -45,6 +47,23 @@ type Object struct {
 	Qualified string
 }
 
+// A Proxy is a type that implements an interface type by calling the methods of
+// a value declared in the session. See interp.Proxy.
+type Proxy struct {
+	Name       string
+	TypeIndex  int    // The index of the interface type in the package's Types
+	TypeString string // The interface type
+	Methods    []ProxyMethod
+}
+
+type ProxyMethod struct {
+	Name        string
+	Params      string   // The parameters, named a0, a1, and so on
+	Args        string   // The parameters as reflect.Values
+	Results     string   // The results, named r0, r1, and so on
+	ResultTypes []string // The types of the results
+}
+
 type Interp struct {
 	Imports  []Import
 	Packages []Package
@@ -214,6 +233,9 @@ func processType(pkg *Package, typ types.Type, checkerTypeStr string, reflectTyp
 	// First, add the type itself
 	addType(pkg, typ, checkerTypeStr, reflectTypeStr, useReflectString)
 
+	// If it's a named interface type, add a proxy for session values to implement it
+	processProxy(pkg, typ, index, pkgNames)
+
 	// If it's a (nameless) channel type, add the other two directions
 	switch typ := typ.(type) {
 	case *types.Chan:
@@ -307,9 +329,9 @@ func processType(pkg *Package, typ types.Type, checkerTypeStr string, reflectTyp
 		}
 	}
 
-	// TODO: Add (or process?) the method type for each method in the type's method set
-	// What does "the method type" mean?
-	// Suppose we have the following definitions:
+	// Add the type of each method value of the type, so that method values get
+	// a real function type rather than a simulated one. Suppose we have the
+	// following definitions:
 	//
 	// type MyInt int
 	//
@@ -319,30 +341,12 @@ func processType(pkg *Package, typ types.Type, checkerTypeStr string, reflectTyp
 	//
 	// var n = MyInt(17)
 	//
-	// Then we can obtain a function type representing this method in a few ways:
-	// 1) MyInt.Foo
-	//    -> func(MyInt, MyInt) MyInt
-	// 2) (*MyInt).Foo
-	//    -> func(*MyInt, MyInt) MyInt
-	// 3) n.Foo
-	//    -> func(MyInt) MyInt
-	// 4) (&n).Foo  (ditto)
-	//    -> func(MyInt) MyInt
-	//
-	// Method expressions (1) and (2) are obtainable because the type is writable.
-	// Method values (3) and (4) are obtainable because the type is obtainable.
-	// Also note that (3) and (4) have the same type, so processing both in this way is redundant
-	// but not harmful.
-	//
-	// The reflect package gives us a way to get most of these easily.
-	// reflect.Type has "Method" and "MethodByName" methods that return (1) (or (2) if called on the ptr type).
-	//   -> This is true unless typ is an interface type. If it's an interface type, then these
-	//      methods on reflect.Type give the same type as (3) and (4). In that case, we can't get
-	//      our hands on the method type as a reflect.Type to put in the typemap, but if we don't
-	//      pick it up somewhere else (externally obtainable), we can simulate it easily with a closure.
-	//
-	// reflect.Value has "Method" and "MethodByName" methods that return (3) or (4).
-	//
+	// Then n.Foo and (&n).Foo both have type func(MyInt) MyInt. The method set of
+	// *MyInt includes the methods of MyInt, so we use that one (unless the type
+	// is an interface type). The reflect package only knows about exported
+	// methods, so we skip the others. Method expressions such as MyInt.Foo get a
+	// simulated type when there's no other way to obtain one.
+	processMethods(pkg, typ, index, pkgNames)
 
 	// If underlying type is writable, add it, too
 	// (we don't need to process it recursively because it has the same components as the current type,
@@ -354,6 +358,93 @@ func processType(pkg *Package, typ types.Type, checkerTypeStr string, reflectTyp
 	}
 }
 
+func processMethods(pkg *Package, typ types.Type, index int, pkgNames map[string]bool) {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return
+	}
+	var msetTyp types.Type = types.NewPointer(named)
+	msetCts := fmt.Sprintf("types.NewPointer(t%d)", index)
+	msetRts := fmt.Sprintf("reflect.PtrTo(rt%d)", index)
+	if _, isIface := named.Underlying().(*types.Interface); isIface {
+		msetTyp = named
+		msetCts = fmt.Sprintf("t%d", index)
+		msetRts = fmt.Sprintf("rt%d", index)
+	}
+	mset := types.NewMethodSet(msetTyp)
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i).Obj()
+		if !m.Exported() {
+			continue
+		}
+		mcts := fmt.Sprintf("types.NewMethodSet(%s).Lookup(nil, %q).Obj().Type()", msetCts, m.Name())
+		mrts := fmt.Sprintf("methodValueType(%s, %q)", msetRts, m.Name())
+		processType(pkg, m.Type(), mcts, mrts, true, pkgNames)
+	}
+}
+
+// processProxy adds a proxy for typ, if it's a named interface type that can be
+// implemented outside its package. The methods of the proxy have to be written
+// in the generated program, so their types must be writable, too.
+func processProxy(pkg *Package, typ types.Type, index int, pkgNames map[string]bool) {
+	named, ok := typ.(*types.Named)
+	if !ok || !isWritable(named, pkgNames) {
+		return
+	}
+	if _, isIface := named.Underlying().(*types.Interface); !isIface {
+		return
+	}
+	mset := types.NewMethodSet(named)
+	if mset.Len() == 0 {
+		return
+	}
+	// The predeclared type error has no package
+	name := "proxy_" + named.Obj().Name()
+	if tpkg := named.Obj().Pkg(); tpkg != nil {
+		name = fmt.Sprintf("proxy_%s_%s", tpkg.Name(), named.Obj().Name())
+	}
+	proxy := Proxy{
+		Name:       name,
+		TypeIndex:  index,
+		TypeString: interp.TypeString(named),
+	}
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i).Obj()
+		// A method named Proxy would clash with the embedded *interp.Proxy
+		if !m.Exported() || m.Name() == "Proxy" || !isWritable(m.Type(), pkgNames) {
+			return
+		}
+		sig := m.Type().(*types.Signature)
+		var params, args, results, resultTypes []string
+		for j := 0; j < sig.Params().Len(); j++ {
+			pt := sig.Params().At(j).Type()
+			pts := interp.TypeString(pt)
+			if sig.Variadic() && j == sig.Params().Len()-1 {
+				pts = "..." + interp.TypeString(pt.(*types.Slice).Elem())
+			}
+			params = append(params, fmt.Sprintf("a%d %s", j, pts))
+			// Take the address, so that interface values keep their type
+			args = append(args, fmt.Sprintf("reflect.ValueOf(&a%d).Elem()", j))
+		}
+		for j := 0; j < sig.Results().Len(); j++ {
+			rts := interp.TypeString(sig.Results().At(j).Type())
+			results = append(results, fmt.Sprintf("r%d %s", j, rts))
+			resultTypes = append(resultTypes, rts)
+		}
+		pm := ProxyMethod{
+			Name:        m.Name(),
+			Params:      strings.Join(params, ", "),
+			Args:        strings.Join(args, ", "),
+			ResultTypes: resultTypes,
+		}
+		if len(results) > 0 {
+			pm.Results = "(" + strings.Join(results, ", ") + ")"
+		}
+		proxy.Methods = append(proxy.Methods, pm)
+	}
+	pkg.Proxies = append(pkg.Proxies, proxy)
+}
+
 func processVar(pkg *Package, obj types.Object, pkgNames map[string]bool) {
 	if !obj.Exported() {
 		return
@@ -461,6 +552,7 @@ func main() {
 			Name: {{printf "%q" .Name}},
 			Pkg:  tpkg,
 			Objs: map[string]interp.Object{},
+			Proxies: map[types.Type]interp.ProxyMaker{},
 		}
 		pkgs = append(pkgs, pkg)
 
@@ -471,6 +563,11 @@ func main() {
 		{{else}}rt{{$index}} := {{$typ.ReflectString}}
 		{{end}}typeMap.Set(t{{$index}}, rt{{$index}})
 	{{end}}
+	{{range .Proxies}}
+		pkg.Proxies[t{{.TypeIndex}}] = func(p *interp.Proxy) interface{} {
+			return {{.Name}}{p}
+		}
+	{{end}}
 	{{range .Objects}}
 		pkg.Objs[{{printf "%q" .Name}}] = interp.Object{
 			Value: reflect.ValueOf({{.Qualified}}),
@@ -499,7 +596,9 @@ func main() {
 		incomplete, err := interp.Run(src)
 		if err != nil {
 			fmt.Println(err)
-			break
+			if _, pending := err.(*interp.PendingError); !pending {
+				break
+			}
 		}
 		if src != "" {
 			line.AppendHistory(src)
@@ -511,4 +610,33 @@ func main() {
 		}
 	}
 }
-`
+{{if .Packages}}
+// methodValueType returns the type of a method value x.name, where x has type rt.
+func methodValueType(rt reflect.Type, name string) reflect.Type {
+	m, _ := rt.MethodByName(name)
+	if rt.Kind() == reflect.Interface {
+		// The method of an interface type has no receiver
+		return m.Type
+	}
+	in := make([]reflect.Type, m.Type.NumIn()-1)
+	for i := range in {
+		in[i] = m.Type.In(i + 1)
+	}
+	out := make([]reflect.Type, m.Type.NumOut())
+	for i := range out {
+		out[i] = m.Type.Out(i)
+	}
+	return reflect.FuncOf(in, out, m.Type.IsVariadic())
+}
+{{end}}{{range .Packages}}{{range .Proxies}}
+// {{.Name}} implements {{.TypeString}} for a value declared in the session.
+type {{.Name}} struct {
+	*interp.Proxy
+}
+{{$proxy := .Name}}{{range .Methods}}
+func (p {{$proxy}}) {{.Name}}({{.Params}}) {{.Results}} {
+	{{if .ResultTypes}}out := {{end}}p.Proxy.Call({{printf "%q" .Name}}, []reflect.Value{ {{.Args}} })
+	{{range $i, $t := .ResultTypes}}r{{$i}}, _ = out[{{$i}}].Interface().({{$t}})
+	{{end}}return
+}
+{{end}}{{end}}{{end}}`